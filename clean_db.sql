@@ -1,13 +1,41 @@
 -- Очистка таблиц в правильном порядке (с учетом внешних ключей)
+DELETE FROM saved_items;
+DELETE FROM wishlists;
+DELETE FROM idempotency_keys;
+DELETE FROM refunds;
+DELETE FROM return_items;
+DELETE FROM order_returns;
+DELETE FROM webhook_events;
+DELETE FROM payments;
+DELETE FROM promotion_redemptions;
+DELETE FROM order_discounts;
+DELETE FROM order_status_history;
 DELETE FROM order_items;
 DELETE FROM orders;
 DELETE FROM cart_items;
 DELETE FROM carts;
 DELETE FROM products;
+DELETE FROM promotions;
+DELETE FROM addresses;
+DELETE FROM users;
 
 -- Сброс автоинкрементных счетчиков
+ALTER SEQUENCE saved_items_id_seq RESTART WITH 1;
+ALTER SEQUENCE wishlists_id_seq RESTART WITH 1;
+ALTER SEQUENCE idempotency_keys_id_seq RESTART WITH 1;
+ALTER SEQUENCE refunds_id_seq RESTART WITH 1;
+ALTER SEQUENCE return_items_id_seq RESTART WITH 1;
+ALTER SEQUENCE order_returns_id_seq RESTART WITH 1;
+ALTER SEQUENCE webhook_events_id_seq RESTART WITH 1;
+ALTER SEQUENCE payments_id_seq RESTART WITH 1;
+ALTER SEQUENCE promotion_redemptions_id_seq RESTART WITH 1;
+ALTER SEQUENCE order_discounts_id_seq RESTART WITH 1;
+ALTER SEQUENCE order_status_history_id_seq RESTART WITH 1;
 ALTER SEQUENCE order_items_id_seq RESTART WITH 1;
 ALTER SEQUENCE orders_id_seq RESTART WITH 1;
 ALTER SEQUENCE cart_items_id_seq RESTART WITH 1;
 ALTER SEQUENCE carts_id_seq RESTART WITH 1;
-ALTER SEQUENCE products_id_seq RESTART WITH 1; 
\ No newline at end of file
+ALTER SEQUENCE products_id_seq RESTART WITH 1;
+ALTER SEQUENCE promotions_id_seq RESTART WITH 1;
+ALTER SEQUENCE addresses_id_seq RESTART WITH 1;
+ALTER SEQUENCE users_id_seq RESTART WITH 1; 
\ No newline at end of file
@@ -1,12 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	nethttp "net/http"
+	"os"
+	"os/signal"
+	"shopping-cart/internal/auth"
 	"shopping-cart/internal/delivery/http"
 	"shopping-cart/internal/domain"
+	"shopping-cart/internal/payment"
+	"shopping-cart/internal/pricing"
 	repo "shopping-cart/internal/repository/postgres"
 	"shopping-cart/internal/service/impl"
+	"shopping-cart/internal/shipping"
+	"shopping-cart/internal/tax"
+	"shopping-cart/internal/webhook"
+	"shopping-cart/internal/worker"
+	"sync"
+	"syscall"
+	"time"
 
 	_ "shopping-cart/docs" // Импортируем сгенерированную документацию
 
@@ -18,6 +33,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// shutdownTimeout - время, за которое сервер должен завершить начатые запросы при остановке
+const shutdownTimeout = 10 * time.Second
+
 // @title Shopping Cart API
 // @version 1.0
 // @description REST API для управления корзиной товаров в интернет-магазине
@@ -41,33 +59,179 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	// Объединение повторяющихся корзин и позиций перед созданием уникальных индексов
+	if err := repo.MergeDuplicateCarts(db); err != nil {
+		log.Fatal("Failed to merge duplicate carts:", err)
+	}
+
+	// Заглушки пользователей для корзин и заказов, сохраненных до появления пользователей,
+	// чтобы AutoMigrate смог добавить внешние ключи на users
+	if err := repo.AdoptOrphanedUsers(db); err != nil {
+		log.Fatal("Failed to adopt orphaned users:", err)
+	}
+
+	// Гостевые корзины не привязаны к пользователю
+	if err := repo.AllowGuestCarts(db); err != nil {
+		log.Fatal("Failed to allow guest carts:", err)
+	}
+
+	// Позиции корзин, добавленные до появления снимка цены, получают текущие цены товаров
+	backfillCartPrices := db.Migrator().HasTable(&domain.CartItem{}) && !db.Migrator().HasColumn(&domain.CartItem{}, "price_amount")
+
 	// Автоматическая миграция схемы базы данных
 	if err := db.AutoMigrate(
+		&domain.User{},
+		&domain.Address{},
 		&domain.Product{},
 		&domain.Cart{},
 		&domain.CartItem{},
 		&domain.Order{},
 		&domain.OrderItem{},
+		&domain.OrderStatusHistory{},
+		&domain.OrderDiscount{},
+		&domain.Promotion{},
+		&domain.PromotionRedemption{},
+		&domain.Payment{},
+		&domain.WebhookEvent{},
+		&domain.OrderReturn{},
+		&domain.ReturnItem{},
+		&domain.Refund{},
+		&domain.IdempotencyKey{},
+		&domain.Wishlist{},
+		&domain.SavedItem{},
 	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	// Перенос денежных сумм из устаревших float-колонок
+	if err := repo.MigrateMoneyColumns(db, domain.DefaultCurrency); err != nil {
+		log.Fatal("Failed to migrate money columns:", err)
+	}
+	if err := repo.CreateProductIndexes(db); err != nil {
+		log.Fatal("Failed to create product indexes:", err)
+	}
+	if backfillCartPrices {
+		if err := repo.BackfillCartItemPrices(db); err != nil {
+			log.Fatal("Failed to backfill cart item prices:", err)
+		}
+	}
+
 	// Инициализация репозиториев
 	cartRepo := repo.NewCartRepository(db)
 	cartItemRepo := repo.NewCartItemRepository(db)
 	orderRepo := repo.NewOrderRepository(db)
 	productRepo := repo.NewProductRepository(db)
+	userRepo := repo.NewUserRepository(db)
+	promotionRepo := repo.NewPromotionRepository(db)
+	addressRepo := repo.NewAddressRepository(db)
+	paymentRepo := repo.NewPaymentRepository(db)
+	webhookEventRepo := repo.NewWebhookEventRepository(db)
+	returnRepo := repo.NewReturnRepository(db)
+	idempotencyKeyRepo := repo.NewIdempotencyKeyRepository(db)
+	wishlistRepo := repo.NewWishlistRepository(db)
+	unitOfWork := repo.NewUnitOfWork(db)
+
+	// Загрузка налоговых ставок
+	taxConfig := os.Getenv("TAX_CONFIG")
+	if taxConfig == "" {
+		taxConfig = "config/tax.json"
+	}
+	taxTable, err := tax.LoadTable(taxConfig)
+	if err != nil {
+		log.Fatal("Failed to load tax config:", err)
+	}
+
+	// Загрузка тарифов доставки
+	shippingConfig := os.Getenv("SHIPPING_CONFIG")
+	if shippingConfig == "" {
+		shippingConfig = "config/shipping.json"
+	}
+	shippingTable, err := shipping.LoadTable(shippingConfig)
+	if err != nil {
+		log.Fatal("Failed to load shipping config:", err)
+	}
+
+	// Расчет стоимости корзины, общий для корзины и оформления заказа
+	pricingEngine := pricing.NewEngine(taxTable, shippingTable)
+
+	// Платежный шлюз: пока доступен только локальный фейковый шлюз,
+	// отказы которого задаются через PAYMENT_FAKE_FAILURES
+	paymentFailures, err := payment.ParseFailures(os.Getenv("PAYMENT_FAKE_FAILURES"))
+	if err != nil {
+		log.Fatal("Invalid PAYMENT_FAKE_FAILURES:", err)
+	}
+	paymentGateway := payment.NewFakeGateway(paymentFailures)
 
 	// Инициализация сервисов
-	cartService := impl.NewCartService(cartRepo, cartItemRepo, productRepo)
-	orderService := impl.NewOrderService(orderRepo, cartRepo, cartItemRepo, productRepo)
-	productService := impl.NewProductService(productRepo)
+	cartService := impl.NewCartService(cartRepo, cartItemRepo, productRepo, promotionRepo, unitOfWork, pricingEngine)
+	orderService := impl.NewOrderService(orderRepo, cartRepo, cartItemRepo, productRepo, addressRepo, unitOfWork, pricingEngine, paymentGateway)
+	productService := impl.NewProductService(productRepo, taxTable)
+	userService := impl.NewUserService(userRepo)
+	promotionService := impl.NewPromotionService(promotionRepo)
+	addressService := impl.NewAddressService(addressRepo)
+	paymentService := impl.NewPaymentService(orderRepo, paymentRepo, unitOfWork, paymentGateway)
+	webhookService := impl.NewWebhookService(webhookEventRepo, orderRepo, orderService)
+	returnService := impl.NewReturnService(orderRepo, returnRepo, unitOfWork, paymentGateway)
+	wishlistService := impl.NewWishlistService(wishlistRepo, productRepo, unitOfWork)
+
+	// Ключи идемпотентности хранятся IDEMPOTENCY_KEY_TTL, после чего могут быть использованы заново
+	idempotencyTTL := 24 * time.Hour
+	if ttl := os.Getenv("IDEMPOTENCY_KEY_TTL"); ttl != "" {
+		if idempotencyTTL, err = time.ParseDuration(ttl); err != nil {
+			log.Fatal("Invalid IDEMPOTENCY_KEY_TTL:", err)
+		}
+	}
+	idempotencyService := impl.NewIdempotencyService(idempotencyKeyRepo, idempotencyTTL)
+
+	// Корзины, которые не менялись дольше CART_TTL, удаляются раз в CART_SWEEP_INTERVAL
+	// CART_TTL=0 отключает удаление
+	cartTTL := 30 * 24 * time.Hour
+	if ttl := os.Getenv("CART_TTL"); ttl != "" {
+		if cartTTL, err = time.ParseDuration(ttl); err != nil {
+			log.Fatal("Invalid CART_TTL:", err)
+		}
+	}
+	cartSweepInterval := time.Hour
+	if interval := os.Getenv("CART_SWEEP_INTERVAL"); interval != "" {
+		if cartSweepInterval, err = time.ParseDuration(interval); err != nil || cartSweepInterval <= 0 {
+			log.Fatal("Invalid CART_SWEEP_INTERVAL:", interval)
+		}
+	}
+	cartExpiryService := impl.NewCartExpiryService(cartRepo, cartTTL)
+
+	// Инициализация менеджера токенов аутентификации
+	authSecret := os.Getenv("AUTH_SECRET")
+	if authSecret == "" {
+		log.Fatal("AUTH_SECRET is not set")
+	}
+	tokenTTL := 24 * time.Hour
+	if ttl := os.Getenv("AUTH_TOKEN_TTL"); ttl != "" {
+		if tokenTTL, err = time.ParseDuration(ttl); err != nil {
+			log.Fatal("Invalid AUTH_TOKEN_TTL:", err)
+		}
+	}
+	tokenManager := auth.NewTokenManager(authSecret, tokenTTL)
+
+	// Проверка подписи входящих вебхуков
+	// Без WEBHOOK_SECRET вебхуки отклоняются, остальной API работает
+	webhookSecret := os.Getenv("WEBHOOK_SECRET")
+	if webhookSecret == "" {
+		log.Println("WEBHOOK_SECRET is not set, incoming webhooks will be rejected")
+	}
+	webhookTolerance := 5 * time.Minute
+	if tolerance := os.Getenv("WEBHOOK_TOLERANCE"); tolerance != "" {
+		if webhookTolerance, err = time.ParseDuration(tolerance); err != nil {
+			log.Fatal("Invalid WEBHOOK_TOLERANCE:", err)
+		}
+	}
+	webhookSigner := webhook.NewSigner(webhookSecret, webhookTolerance)
 
 	// Инициализация HTTP-обработчика
-	handler := http.NewHandler(cartService, orderService, productService)
+	handler := http.NewHandler(cartService, orderService, productService, userService, promotionService, addressService, paymentService, webhookService, returnService, idempotencyService, cartExpiryService, wishlistService, tokenManager, http.AuthMiddleware(tokenManager, userService), http.WebhookSignature(webhookSigner))
 
 	// Инициализация маршрутизатора Gin
 	router := gin.Default()
+	router.Use(http.ErrorHandler())
 
 	// Добавляем Swagger
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -81,8 +245,42 @@ func main() {
 		port = "8080"
 	}
 
+	// Остановка по SIGINT или SIGTERM: сервер завершает начатые запросы,
+	// фоновые задачи - текущий проход
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Запуск фоновой очистки корзин
+	var workers sync.WaitGroup
+	if cartTTL > 0 {
+		sweeper := worker.NewCartSweeper(cartExpiryService, cartSweepInterval)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			sweeper.Run(ctx)
+		}()
+	}
+
 	// Запуск HTTP-сервера
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	server := &nethttp.Server{Addr: ":" + port, Handler: router}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, nethttp.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	case <-ctx.Done():
+		log.Println("Shutting down server...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Println("Server shutdown:", err)
+		}
 	}
+	stop()
+	workers.Wait()
 }
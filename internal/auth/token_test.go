@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenManager(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	manager := NewTokenManager("secret", time.Hour)
+	manager.now = func() time.Time { return now }
+
+	token, err := manager.Issue(42)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		token         string
+		verifier      *TokenManager
+		at            time.Time
+		expectedUser  uint
+		expectedError error
+	}{
+		{
+			name:         "Валидный токен",
+			token:        token,
+			verifier:     manager,
+			at:           now.Add(time.Minute),
+			expectedUser: 42,
+		},
+		{
+			name:          "Истекший токен",
+			token:         token,
+			verifier:      manager,
+			at:            now.Add(2 * time.Hour),
+			expectedError: ErrExpiredToken,
+		},
+		{
+			name:          "Чужой ключ подписи",
+			token:         token,
+			verifier:      NewTokenManager("other", time.Hour),
+			at:            now,
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name:          "Поврежденный токен",
+			token:         token[:len(token)-2],
+			verifier:      manager,
+			at:            now,
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name:          "Мусор вместо токена",
+			token:         "not-a-token",
+			verifier:      manager,
+			at:            now,
+			expectedError: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := tt.at
+			tt.verifier.now = func() time.Time { return at }
+
+			claims, err := tt.verifier.Verify(tt.token)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+
+			userID, err := claims.UserID()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedUser, userID)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"net/http"
+	"shopping-cart/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAddresses возвращает адресную книгу текущего пользователя
+func (h *Handler) GetAddresses(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	addresses, err := h.addressService.GetAddresses(userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, addresses)
+}
+
+// @Summary Добавить адрес
+// @Description Добавляет адрес в адресную книгу текущего пользователя
+// @Tags address
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param address body domain.Address true "Адрес"
+// @Success 201 {object} domain.Address
+// @Failure 400 {object} ErrorResponse
+// @Router /users/me/addresses [post]
+func (h *Handler) CreateAddress(c *gin.Context) {
+	var address domain.Address
+	if err := c.ShouldBindJSON(&address); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.addressService.CreateAddress(userID, &address); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, address)
+}
+
+// @Summary Обновить адрес
+// @Description Обновляет адрес из адресной книги текущего пользователя
+// @Tags address
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID адреса"
+// @Param address body domain.Address true "Адрес"
+// @Success 200 {object} domain.Address
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/me/addresses/{id} [put]
+func (h *Handler) UpdateAddress(c *gin.Context) {
+	addressID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var address domain.Address
+	if err := c.ShouldBindJSON(&address); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+	address.ID = addressID
+
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.addressService.UpdateAddress(userID, &address); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, address)
+}
+
+// DeleteAddress удаляет адрес из адресной книги текущего пользователя
+func (h *Handler) DeleteAddress(c *gin.Context) {
+	addressID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.addressService.DeleteAddress(userID, addressID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"shopping-cart/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authResponse - ответ на успешную регистрацию или вход
+type authResponse struct {
+	Token string       `json:"token"`
+	User  *domain.User `json:"user"`
+}
+
+// @Summary Регистрация пользователя
+// @Description Создает учетную запись и возвращает bearer-токен. Гостевая корзина переносится в корзину пользователя
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Токен гостевой корзины"
+// @Success 201 {object} authResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/register [post]
+func (h *Handler) Register(c *gin.Context) {
+	var request struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required,min=8,max=72"`
+		Name     string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	user, err := h.userService.Register(request.Email, request.Password, request.Name)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	token, err := h.tokens.Issue(user.ID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	h.mergeGuestCart(c, user.ID)
+	c.JSON(http.StatusCreated, authResponse{Token: token, User: user})
+}
+
+// @Summary Вход пользователя
+// @Description Проверяет email и пароль и возвращает bearer-токен. Гостевая корзина переносится в корзину пользователя
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param X-Cart-Token header string false "Токен гостевой корзины"
+// @Success 200 {object} authResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/login [post]
+func (h *Handler) Login(c *gin.Context) {
+	var request struct {
+		Email    string `json:"email" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	user, err := h.userService.Login(request.Email, request.Password)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	token, err := h.tokens.Issue(user.ID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	h.mergeGuestCart(c, user.ID)
+	c.JSON(http.StatusOK, authResponse{Token: token, User: user})
+}
+
+// mergeGuestCart переносит гостевую корзину из запроса в корзину вошедшего пользователя
+// и удаляет cookie гостевой корзины. Ошибка слияния не мешает входу и только пишется в лог
+func (h *Handler) mergeGuestCart(c *gin.Context, userID uint) {
+	token := cartTokenFromRequest(c)
+	if !domain.IsCartToken(token) {
+		return
+	}
+	if _, err := h.cartService.MergeCart(token, userID); err != nil {
+		log.Printf("merge guest cart into cart of user %d: %v", userID, err)
+		return
+	}
+	setCartTokenCookie(c, "", -1)
+}
+
+// ChangePassword меняет пароль текущего пользователя
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var request struct {
+		OldPassword string `json:"old_password" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=8,max=72"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.userService.ChangePassword(userID, request.OldPassword, request.NewPassword); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SetUserRole назначает роль пользователю (только для администраторов)
+func (h *Handler) SetUserRole(c *gin.Context) {
+	var request struct {
+		Role domain.Role `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.userService.SetRole(userID, request.Role); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
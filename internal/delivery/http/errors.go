@@ -0,0 +1,88 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"shopping-cart/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ErrorResponse - единый формат ответа с ошибкой
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody содержит машиночитаемый код и описание ошибки
+type ErrorBody struct {
+	Code    domain.ErrorCode `json:"code"`
+	Message string           `json:"message"`
+	Details map[string]any   `json:"details,omitempty"`
+}
+
+// statusByCode сопоставляет коды доменных ошибок HTTP-статусам
+var statusByCode = map[domain.ErrorCode]int{
+	domain.CodeNotFound:          http.StatusNotFound,
+	domain.CodeConflict:          http.StatusConflict,
+	domain.CodeValidation:        http.StatusBadRequest,
+	domain.CodeUnauthorized:      http.StatusUnauthorized,
+	domain.CodeForbidden:         http.StatusForbidden,
+	domain.CodeEmptyCart:         http.StatusUnprocessableEntity,
+	domain.CodeInsufficientStock: http.StatusConflict,
+	domain.CodePaymentDeclined:   http.StatusPaymentRequired,
+	domain.CodePaymentTimeout:    http.StatusGatewayTimeout,
+	domain.CodePriceChanged:      http.StatusConflict,
+}
+
+// ErrorHandler отображает последнюю ошибку, добавленную через c.Error,
+// в JSON-ответ с соответствующим статусом. Подключается глобально
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		respondWithError(c)
+	}
+}
+
+// respondWithError записывает ответ с последней ошибкой запроса, если ответ еще не записан
+func respondWithError(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	status, body := renderError(c.Errors.Last().Err)
+	if status == http.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, c.Errors.Last().Err)
+	}
+	c.JSON(status, ErrorResponse{Error: body})
+}
+
+// renderError переводит ошибку в HTTP-статус и тело ответа
+// Неизвестные ошибки скрываются, чтобы не раскрывать детали хранилища
+func renderError(err error) (int, ErrorBody) {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		status, ok := statusByCode[domainErr.Code]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		message := domainErr.Message
+		if message == "" {
+			message = string(domainErr.Code)
+		}
+		return status, ErrorBody{Code: domainErr.Code, Message: message, Details: domainErr.Details}
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound, ErrorBody{Code: domain.CodeNotFound, Message: "resource not found"}
+	}
+
+	return http.StatusInternalServerError, ErrorBody{Code: domain.CodeInternal, Message: "internal server error"}
+}
+
+// abortWithError передает ошибку в ErrorHandler и прерывает цепочку обработчиков
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"shopping-cart/internal/domain"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/items/:id", func(c *gin.Context) {
+		id, ok := parseIDParam(c, "id")
+		if !ok {
+			return
+		}
+		switch id {
+		case 404:
+			abortWithError(c, fmt.Errorf("lookup: %w", gorm.ErrRecordNotFound))
+		case 409:
+			abortWithError(c, domain.NewInsufficientStockError(domain.StockShortage{ProductID: 1, Requested: 5, Available: 2}))
+		case 4091:
+			abortWithError(c, domain.NewPriceChangedError(domain.PriceChange{ItemID: 3, ProductID: 1, OldPrice: domain.NewMoney(1000, "RUB"), NewPrice: domain.NewMoney(1200, "RUB")}))
+		case 402:
+			abortWithError(c, domain.NewPaymentDeclinedError(errors.New("card declined")))
+		case 422:
+			abortWithError(c, domain.ErrEmptyCart)
+		case 500:
+			abortWithError(c, errors.New(`pq: relation "carts" does not exist`))
+		default:
+			c.JSON(http.StatusOK, gin.H{"id": id})
+		}
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedCode   domain.ErrorCode
+	}{
+		{name: "Корректный ID", path: "/items/5", expectedStatus: http.StatusOK},
+		{name: "Нечисловой ID", path: "/items/abc", expectedStatus: http.StatusBadRequest, expectedCode: domain.CodeValidation},
+		{name: "Нулевой ID", path: "/items/0", expectedStatus: http.StatusBadRequest, expectedCode: domain.CodeValidation},
+		{name: "Отрицательный ID", path: "/items/-1", expectedStatus: http.StatusBadRequest, expectedCode: domain.CodeValidation},
+		{name: "Запись не найдена", path: "/items/404", expectedStatus: http.StatusNotFound, expectedCode: domain.CodeNotFound},
+		{name: "Нехватка товара", path: "/items/409", expectedStatus: http.StatusConflict, expectedCode: domain.CodeInsufficientStock},
+		{name: "Цены изменились", path: "/items/4091", expectedStatus: http.StatusConflict, expectedCode: domain.CodePriceChanged},
+		{name: "Платеж отклонен", path: "/items/402", expectedStatus: http.StatusPaymentRequired, expectedCode: domain.CodePaymentDeclined},
+		{name: "Пустая корзина", path: "/items/422", expectedStatus: http.StatusUnprocessableEntity, expectedCode: domain.CodeEmptyCart},
+		{name: "Внутренняя ошибка", path: "/items/500", expectedStatus: http.StatusInternalServerError, expectedCode: domain.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode == "" {
+				return
+			}
+
+			var response ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedCode, response.Error.Code)
+			assert.NotContains(t, response.Error.Message, "pq:")
+		})
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/http"
+	"shopping-cart/internal/auth"
 	"shopping-cart/internal/domain"
 	"shopping-cart/internal/service"
 
@@ -10,64 +11,172 @@ import (
 
 // Handler обрабатывает HTTP-запросы
 type Handler struct {
-	cartService    service.CartService
-	orderService   service.OrderService
-	productService service.ProductService
+	cartService        service.CartService
+	orderService       service.OrderService
+	productService     service.ProductService
+	userService        service.UserService
+	promotionService   service.PromotionService
+	addressService     service.AddressService
+	paymentService     service.PaymentService
+	webhookService     service.WebhookService
+	returnService      service.ReturnService
+	idempotencyService service.IdempotencyService
+	cartExpiryService  service.CartExpiryService
+	wishlistService    service.WishlistService
+	tokens             auth.Issuer
+	authMiddleware     gin.HandlerFunc
+	webhookMiddleware  gin.HandlerFunc
 }
 
 // NewHandler создает новый экземпляр HTTP-обработчика
-func NewHandler(cartService service.CartService, orderService service.OrderService, productService service.ProductService) *Handler {
+func NewHandler(cartService service.CartService, orderService service.OrderService, productService service.ProductService, userService service.UserService, promotionService service.PromotionService, addressService service.AddressService, paymentService service.PaymentService, webhookService service.WebhookService, returnService service.ReturnService, idempotencyService service.IdempotencyService, cartExpiryService service.CartExpiryService, wishlistService service.WishlistService, tokens auth.Issuer, authMiddleware gin.HandlerFunc, webhookMiddleware gin.HandlerFunc) *Handler {
 	return &Handler{
-		cartService:    cartService,
-		orderService:   orderService,
-		productService: productService,
+		cartService:        cartService,
+		orderService:       orderService,
+		productService:     productService,
+		userService:        userService,
+		promotionService:   promotionService,
+		addressService:     addressService,
+		paymentService:     paymentService,
+		webhookService:     webhookService,
+		returnService:      returnService,
+		idempotencyService: idempotencyService,
+		cartExpiryService:  cartExpiryService,
+		wishlistService:    wishlistService,
+		tokens:             tokens,
+		authMiddleware:     authMiddleware,
+		webhookMiddleware:  webhookMiddleware,
 	}
 }
 
 // RegisterRoutes регистрирует маршруты API
 func (h *Handler) RegisterRoutes(router *gin.Engine) {
+	// Auth routes
+	authGroup := router.Group("/api/auth")
+	{
+		authGroup.POST("/register", h.Register)
+		authGroup.POST("/login", h.Login)
+		authGroup.PUT("/password", h.authMiddleware, h.ChangePassword)
+	}
+
 	// Cart routes
-	cart := router.Group("/api/cart")
+	cart := router.Group("/api/cart", OptionalAuth(h.authMiddleware), CartSession())
 	{
 		cart.GET("/", h.GetCart)
-		cart.POST("/items", h.AddItem)
+		cart.POST("/items", Idempotency(h.idempotencyService), h.AddItem)
+		cart.PATCH("/items/:id", h.UpdateItemQuantity)
 		cart.DELETE("/items/:id", h.RemoveItem)
 		cart.DELETE("/", h.ClearCart)
+		cart.POST("/coupon", h.ApplyCoupon)
+		cart.DELETE("/coupon", h.RemoveCoupon)
+		cart.POST("/prices/accept", h.AcceptPriceChanges)
+		cart.POST("/items/:id/save", h.SaveCartItem)
+	}
+
+	// Wishlist routes
+	wishlist := router.Group("/api/wishlist", h.authMiddleware)
+	{
+		wishlist.GET("/", h.GetWishlist)
+		wishlist.POST("/items", h.SaveProduct)
+		wishlist.DELETE("/items/:id", h.RemoveSavedItem)
+		wishlist.POST("/items/:id/move-to-cart", h.MoveSavedItemToCart)
+		wishlist.POST("/share", h.ShareWishlist)
+		wishlist.DELETE("/share", h.UnshareWishlist)
 	}
 
+	// Shared wishlists are public and read-only
+	router.GET("/api/wishlists/:slug", h.GetSharedWishlist)
+
 	// Order routes
-	orders := router.Group("/api/orders")
+	orders := router.Group("/api/orders", h.authMiddleware)
 	{
-		orders.POST("/", h.CreateOrder)
+		orders.POST("/", Idempotency(h.idempotencyService), h.CreateOrder)
 		orders.GET("/:id", h.GetOrder)
 		orders.GET("/", h.GetUserOrders)
-		orders.PATCH("/:id/status", h.UpdateOrderStatus)
+		orders.GET("/:id/history", h.GetOrderHistory)
+		orders.POST("/:id/pay", h.PayOrder)
+		orders.POST("/:id/cancel", h.CancelOrder)
+		orders.POST("/:id/returns", h.RequestReturn)
+		orders.GET("/:id/returns", h.GetOrderReturns)
+		orders.PATCH("/:id/status", RequirePermission(domain.PermissionManageOrders), h.UpdateOrderStatus)
 	}
 
 	// Product routes
 	products := router.Group("/api/products")
 	{
-		products.POST("/", h.CreateProduct)
 		products.GET("/:id", h.GetProduct)
-		products.GET("/", h.GetAllProducts)
-		products.PUT("/:id", h.UpdateProduct)
-		products.DELETE("/:id", h.DeleteProduct)
+		products.GET("/", h.ListProducts)
+	}
+
+	// Catalog management routes
+	catalog := router.Group("/api/products", h.authMiddleware, RequirePermission(domain.PermissionManageCatalog))
+	{
+		catalog.POST("/", h.CreateProduct)
+		catalog.PUT("/:id", h.UpdateProduct)
+		catalog.DELETE("/:id", h.DeleteProduct)
+		catalog.PUT("/:id/stock", h.SetStock)
+	}
+
+	// Promotion management routes
+	promotions := router.Group("/api/promotions", h.authMiddleware, RequirePermission(domain.PermissionManageCatalog))
+	{
+		promotions.POST("/", h.CreatePromotion)
+		promotions.GET("/", h.GetAllPromotions)
+	}
+
+	// Address book routes
+	addresses := router.Group("/api/users/me/addresses", h.authMiddleware)
+	{
+		addresses.GET("/", h.GetAddresses)
+		addresses.POST("/", h.CreateAddress)
+		addresses.PUT("/:id", h.UpdateAddress)
+		addresses.DELETE("/:id", h.DeleteAddress)
+	}
+
+	// Return review routes
+	returns := router.Group("/api/returns", h.authMiddleware, RequirePermission(domain.PermissionManageOrders))
+	{
+		returns.POST("/:id/approve", h.ApproveReturn)
+		returns.POST("/:id/reject", h.RejectReturn)
+	}
+
+	// Webhook routes: authenticated by signature instead of a bearer token
+	webhooks := router.Group("/api/webhooks", h.webhookMiddleware)
+	{
+		webhooks.POST("/orders", h.HandleOrderWebhook)
+	}
+
+	// Report routes
+	reports := router.Group("/api/reports", h.authMiddleware, RequirePermission(domain.PermissionViewReports))
+	{
+		reports.GET("/abandoned-carts", h.GetAbandonedCarts)
+	}
+
+	// User administration routes
+	users := router.Group("/api/users", h.authMiddleware, RequirePermission(domain.PermissionManageUsers))
+	{
+		users.PATCH("/:id/role", h.SetUserRole)
 	}
 }
 
 // @Summary Получить корзину пользователя
 // @Description Возвращает содержимое корзины пользователя
 // @Tags cart
+// @Security BearerAuth
+// @Param X-Cart-Token header string false "Токен гостевой корзины"
 // @Accept json
 // @Produce json
 // @Success 200 {object} domain.Cart
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} ErrorResponse
 // @Router /cart [get]
 func (h *Handler) GetCart(c *gin.Context) {
-	userID := uint(1) // TODO: Get from auth middleware
-	cart, err := h.cartService.GetCart(userID)
+	owner, ok := requireCartOwner(c)
+	if !ok {
+		return
+	}
+	cart, err := h.cartService.GetCart(owner)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, cart)
@@ -76,12 +185,15 @@ func (h *Handler) GetCart(c *gin.Context) {
 // @Summary Добавить товар в корзину
 // @Description Добавляет указанный товар в корзину пользователя
 // @Tags cart
+// @Security BearerAuth
+// @Param X-Cart-Token header string false "Токен гостевой корзины"
 // @Accept json
 // @Produce json
 // @Param item body domain.CartItem true "Товар для добавления"
+// @Param Idempotency-Key header string false "Ключ идемпотентности"
 // @Success 200 {object} domain.Cart
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /cart/items [post]
 func (h *Handler) AddItem(c *gin.Context) {
 	var request struct {
@@ -89,33 +201,83 @@ func (h *Handler) AddItem(c *gin.Context) {
 		Quantity  int  `json:"quantity" binding:"required,min=1"`
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, domain.NewValidationError(err.Error()))
 		return
 	}
 
-	userID := uint(1) // TODO: Get from auth middleware
-	if err := h.cartService.AddItem(userID, request.ProductID, request.Quantity); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	owner, ok := requireCartOwner(c)
+	if !ok {
+		return
+	}
+	if err := h.cartService.AddItem(owner, request.ProductID, request.Quantity); err != nil {
+		abortWithError(c, err)
 		return
 	}
 	c.Status(http.StatusCreated)
 }
 
+// @Summary Изменить количество товара в корзине
+// @Description Устанавливает количество товара в позиции корзины, 0 удаляет позицию
+// @Tags cart
+// @Security BearerAuth
+// @Param X-Cart-Token header string false "Токен гостевой корзины"
+// @Accept json
+// @Param id path int true "ID элемента корзины"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /cart/items/{id} [patch]
+func (h *Handler) UpdateItemQuantity(c *gin.Context) {
+	itemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Quantity *int `json:"quantity" binding:"required,min=0"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	owner, ok := requireCartOwner(c)
+	if !ok {
+		return
+	}
+	if err := h.cartService.UpdateItemQuantity(owner, itemID, *request.Quantity); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
 // @Summary Удалить товар из корзины
 // @Description Удаляет указанный товар из корзины пользователя
 // @Tags cart
+// @Security BearerAuth
+// @Param X-Cart-Token header string false "Токен гостевой корзины"
 // @Accept json
 // @Produce json
-// @Param item_id path int true "ID товара"
-// @Success 200 {object} domain.Cart
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /cart/items/{item_id} [delete]
+// @Param id path int true "ID элемента корзины"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cart/items/{id} [delete]
 func (h *Handler) RemoveItem(c *gin.Context) {
-	itemID := uint(1) // TODO: Parse from URL
-	userID := uint(1) // TODO: Get from auth middleware
-	if err := h.cartService.RemoveItem(userID, itemID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	itemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	owner, ok := requireCartOwner(c)
+	if !ok {
+		return
+	}
+	if err := h.cartService.RemoveItem(owner, itemID); err != nil {
+		abortWithError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -123,28 +285,94 @@ func (h *Handler) RemoveItem(c *gin.Context) {
 
 // ClearCart очищает корзину
 func (h *Handler) ClearCart(c *gin.Context) {
-	userID := uint(1) // TODO: Get from auth middleware
-	if err := h.cartService.ClearCart(userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	owner, ok := requireCartOwner(c)
+	if !ok {
+		return
+	}
+	if err := h.cartService.ClearCart(owner); err != nil {
+		abortWithError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
+// @Summary Принять новые цены
+// @Description Сохраняет подтвержденные покупателем цены позиций из ответа price_changed при оформлении заказа
+// @Tags cart
+// @Security BearerAuth
+// @Param X-Cart-Token header string false "Токен гостевой корзины"
+// @Accept json
+// @Produce json
+// @Success 200 {object} domain.Cart
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cart/prices/accept [post]
+func (h *Handler) AcceptPriceChanges(c *gin.Context) {
+	var request struct {
+		Lines []struct {
+			ItemID   uint         `json:"item_id" binding:"required"`
+			NewPrice domain.Money `json:"new_price"`
+		} `json:"lines" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	owner, ok := requireCartOwner(c)
+	if !ok {
+		return
+	}
+	accepted := make([]service.AcceptedPrice, 0, len(request.Lines))
+	for _, line := range request.Lines {
+		accepted = append(accepted, service.AcceptedPrice{ItemID: line.ItemID, NewPrice: line.NewPrice})
+	}
+	cart, err := h.cartService.AcceptPriceChanges(owner, accepted)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cart)
+}
+
 // @Summary Создать заказ
-// @Description Создает новый заказ на основе содержимого корзины
+// @Description Создает новый заказ на основе содержимого корзины с доставкой по адресу из адресной книги
 // @Tags order
+// @Security BearerAuth
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности"
 // @Success 201 {object} domain.Order
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /orders [post]
 func (h *Handler) CreateOrder(c *gin.Context) {
-	userID := uint(1) // TODO: Get from auth middleware
-	order, err := h.orderService.CreateOrder(userID)
+	var request struct {
+		ShippingAddressID uint   `json:"shipping_address_id" binding:"required"`
+		BillingAddressID  uint   `json:"billing_address_id"`
+		ShippingMethod    string `json:"shipping_method" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	order, err := h.orderService.CreateOrder(userID, service.CheckoutRequest{
+		ShippingAddressID: request.ShippingAddressID,
+		BillingAddressID:  request.BillingAddressID,
+		ShippingMethod:    request.ShippingMethod,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, order)
@@ -153,18 +381,26 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 // @Summary Получить заказ
 // @Description Возвращает информацию о заказе по его ID
 // @Tags order
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path int true "ID заказа"
 // @Success 200 {object} domain.Order
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /orders/{id} [get]
 func (h *Handler) GetOrder(c *gin.Context) {
-	orderID := uint(1) // TODO: Parse from URL
-	order, err := h.orderService.GetOrder(orderID)
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	order, err := h.orderService.GetOrder(userID, RoleFromContext(c), orderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, order)
@@ -172,28 +408,106 @@ func (h *Handler) GetOrder(c *gin.Context) {
 
 // GetUserOrders возвращает список заказов пользователя
 func (h *Handler) GetUserOrders(c *gin.Context) {
-	userID := uint(1) // TODO: Get from auth middleware
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
 	orders, err := h.orderService.GetUserOrders(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, orders)
 }
 
-// UpdateOrderStatus обновляет статус заказа
+// @Summary История статусов заказа
+// @Description Возвращает журнал изменений статуса заказа
+// @Tags order
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 200 {array} domain.OrderStatusHistory
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /orders/{id}/history [get]
+func (h *Handler) GetOrderHistory(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	history, err := h.orderService.GetOrderHistory(userID, RoleFromContext(c), orderID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// @Summary Отменить заказ
+// @Description Отменяет заказ до его отправки с указанием причины. Остатки возвращаются на склад, оплата - покупателю
+// @Tags order
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 200 {object} domain.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/cancel [post]
+func (h *Handler) CancelOrder(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	order, err := h.orderService.CancelOrder(userID, RoleFromContext(c), orderID, request.Reason)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// UpdateOrderStatus переводит заказ в новый статус
+// Недопустимый переход возвращает 409, отмена без причины - 400
 func (h *Handler) UpdateOrderStatus(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
 	var request struct {
-		Status string `json:"status" binding:"required"`
+		Status domain.OrderStatus `json:"status" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, domain.NewValidationError(err.Error()))
 		return
 	}
 
-	orderID := uint(1) // TODO: Parse from URL
-	if err := h.orderService.UpdateOrderStatus(orderID, request.Status); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.orderService.UpdateOrderStatus(userID, orderID, request.Status); err != nil {
+		abortWithError(c, err)
 		return
 	}
 	c.Status(http.StatusOK)
@@ -206,18 +520,18 @@ func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 // @Produce json
 // @Param product body domain.Product true "Товар для создания"
 // @Success 201 {object} domain.Product
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /products [post]
 func (h *Handler) CreateProduct(c *gin.Context) {
 	var product domain.Product
 	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, domain.NewValidationError(err.Error()))
 		return
 	}
 
 	if err := h.productService.CreateProduct(&product); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, product)
@@ -230,39 +544,119 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 // @Produce json
 // @Param id path int true "ID товара"
 // @Success 200 {object} domain.Product
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /products/{id} [get]
 func (h *Handler) GetProduct(c *gin.Context) {
-	productID := uint(1) // TODO: Parse from URL
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
 	product, err := h.productService.GetProduct(productID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, product)
 }
 
-// GetAllProducts возвращает список всех товаров
-func (h *Handler) GetAllProducts(c *gin.Context) {
-	products, err := h.productService.GetAllProducts()
+// @Summary Каталог товаров
+// @Description Возвращает страницу каталога с фильтрами и сортировкой. Страница задается смещением offset или курсором cursor из next_cursor предыдущей страницы
+// @Tags products
+// @Produce json
+// @Param limit query int false "Размер страницы (1-100, по умолчанию 20)"
+// @Param offset query int false "Смещение от начала выборки"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param sort query string false "Сортировка: newest, price_asc, price_desc, name"
+// @Param name query string false "Подстрока названия"
+// @Param category query string false "Категория"
+// @Param min_price query string false "Минимальная цена, например 10.00"
+// @Param max_price query string false "Максимальная цена"
+// @Param currency query string false "Валюта фильтра по цене (по умолчанию RUB)"
+// @Param in_stock query bool false "Только товары в наличии"
+// @Success 200 {object} domain.ProductPage
+// @Failure 400 {object} ErrorResponse
+// @Router /products [get]
+func (h *Handler) ListProducts(c *gin.Context) {
+	var request struct {
+		Limit    int    `form:"limit"`
+		Offset   int    `form:"offset"`
+		Cursor   string `form:"cursor"`
+		Sort     string `form:"sort"`
+		Name     string `form:"name"`
+		Category string `form:"category"`
+		MinPrice string `form:"min_price"`
+		MaxPrice string `form:"max_price"`
+		Currency string `form:"currency"`
+		InStock  bool   `form:"in_stock"`
+	}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	query := domain.ProductQuery{
+		ProductFilter: domain.ProductFilter{
+			Name:     request.Name,
+			Category: request.Category,
+			InStock:  request.InStock,
+		},
+		Sort:   domain.ProductSort(request.Sort),
+		Limit:  request.Limit,
+		Offset: request.Offset,
+	}
+	var err error
+	if query.MinPrice, err = parsePriceParam(request.MinPrice, request.Currency); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if query.MaxPrice, err = parsePriceParam(request.MaxPrice, request.Currency); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if request.Cursor != "" {
+		if query.After, err = domain.ParseProductCursor(request.Cursor); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+
+	page, err := h.productService.ListProducts(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, products)
+	c.JSON(http.StatusOK, page)
+}
+
+// parsePriceParam разбирает цену из параметра запроса; пустой параметр не задает фильтр
+func parsePriceParam(value, currency string) (*domain.Money, error) {
+	if value == "" {
+		return nil, nil
+	}
+	price, err := domain.ParseMoney(value, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
 }
 
-// UpdateProduct обновляет информацию о товаре
+// UpdateProduct обновляет информацию о товаре, указанном в URL
 func (h *Handler) UpdateProduct(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
 	var product domain.Product
 	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, domain.NewValidationError(err.Error()))
 		return
 	}
 
+	product.ID = productID
 	if err := h.productService.UpdateProduct(&product); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, product)
@@ -270,9 +664,34 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 
 // DeleteProduct удаляет товар
 func (h *Handler) DeleteProduct(c *gin.Context) {
-	productID := uint(1) // TODO: Parse from URL
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
 	if err := h.productService.DeleteProduct(productID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		abortWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SetStock устанавливает остаток товара на складе
+func (h *Handler) SetStock(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Stock *int `json:"stock" binding:"required,min=0"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	if err := h.productService.SetStock(productID, *request.Stock); err != nil {
+		abortWithError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -0,0 +1,322 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"shopping-cart/internal/auth"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/service"
+	"shopping-cart/internal/webhook"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ключи, под которыми данные аутентификации хранятся в контексте Gin
+const (
+	userIDKey    = "userID"
+	roleKey      = "role"
+	cartOwnerKey = "cartOwner"
+)
+
+// AuthMiddleware проверяет bearer-токен из заголовка Authorization
+// и сохраняет идентификатор и роль пользователя в контексте запроса.
+// Роль читается из базы, а не из токена: смена роли, в том числе понижение,
+// действует сразу, а токен удаленного пользователя перестает приниматься
+func AuthMiddleware(verifier auth.Verifier, users service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			abortWithError(c, domain.NewUnauthorizedError("missing bearer token"))
+			return
+		}
+
+		claims, err := verifier.Verify(token)
+		if err != nil {
+			abortWithError(c, domain.NewUnauthorizedError(err.Error()))
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			abortWithError(c, domain.NewUnauthorizedError(err.Error()))
+			return
+		}
+
+		user, err := users.GetUser(userID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				err = domain.NewUnauthorizedError("user not found")
+			}
+			abortWithError(c, err)
+			return
+		}
+		role := user.Role
+		if !role.IsValid() {
+			role = domain.RoleCustomer
+		}
+
+		c.Set(userIDKey, userID)
+		c.Set(roleKey, role)
+		c.Next()
+	}
+}
+
+// OptionalAuth проверяет запрос через auth, только если передан заголовок Authorization;
+// запросы без него проходят как анонимные
+func OptionalAuth(auth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// Токен гостевой корзины
+const (
+	// CartTokenHeader - заголовок, в котором клиент передает и получает токен гостевой корзины
+	CartTokenHeader = "X-Cart-Token"
+	// cartTokenCookie - cookie с токеном гостевой корзины для браузерных клиентов
+	cartTokenCookie = "cart_token"
+	// cartTokenMaxAge - срок жизни cookie гостевой корзины в секундах
+	cartTokenMaxAge = 30 * 24 * 60 * 60
+)
+
+// CartSession определяет владельца корзины: аутентифицированный запрос работает с корзиной
+// пользователя, анонимный - с гостевой по токену из заголовка X-Cart-Token или cookie cart_token.
+// Гостю без действительного токена новый выдается только на изменяющий запрос: чтение
+// без токена работает с пустой корзиной. Токен гостя возвращается в заголовке и cookie.
+// Должен подключаться после OptionalAuth
+func CartSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID, ok := UserIDFromContext(c); ok {
+			c.Set(cartOwnerKey, domain.CartOwner{UserID: userID})
+			c.Next()
+			return
+		}
+
+		token := cartTokenFromRequest(c)
+		if !domain.IsCartToken(token) {
+			if isSafeMethod(c.Request.Method) {
+				c.Set(cartOwnerKey, domain.CartOwner{})
+				c.Next()
+				return
+			}
+			var err error
+			if token, err = domain.NewCartToken(); err != nil {
+				abortWithError(c, err)
+				return
+			}
+		}
+		c.Header(CartTokenHeader, token)
+		setCartTokenCookie(c, token, cartTokenMaxAge)
+		c.Set(cartOwnerKey, domain.CartOwner{GuestToken: token})
+		c.Next()
+	}
+}
+
+// isSafeMethod проверяет, что метод запроса не изменяет данные
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// cartTokenFromRequest возвращает токен гостевой корзины из заголовка или cookie
+func cartTokenFromRequest(c *gin.Context) string {
+	if token := c.GetHeader(CartTokenHeader); token != "" {
+		return token
+	}
+	token, _ := c.Cookie(cartTokenCookie)
+	return token
+}
+
+// setCartTokenCookie сохраняет токен гостевой корзины в cookie, maxAge < 0 удаляет cookie
+func setCartTokenCookie(c *gin.Context, token string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(cartTokenCookie, token, maxAge, "/api", "", c.Request.TLS != nil, true)
+}
+
+// maxWebhookBodySize - максимальный размер тела вебхука
+const maxWebhookBodySize = 1 << 20
+
+// WebhookSignature проверяет подпись тела запроса из заголовка X-Webhook-Signature
+// Прочитанное тело возвращается в запрос, чтобы обработчик мог его разобрать
+func WebhookSignature(verifier webhook.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize))
+		if err != nil {
+			abortWithError(c, domain.NewValidationError("request body is too large or unreadable"))
+			return
+		}
+
+		if err := verifier.Verify(c.GetHeader(webhook.SignatureHeader), body); err != nil {
+			abortWithError(c, domain.NewUnauthorizedError(err.Error()))
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// Заголовки идемпотентных запросов
+const (
+	// IdempotencyKeyHeader - ключ, по которому повтор запроса получает исходный ответ
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader - признак ответа, сохраненного при первом запросе
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotentBodySize - максимальный размер тела идемпотентного запроса
+const maxIdempotentBodySize = 1 << 20
+
+// Idempotency выполняет запрос с заголовком Idempotency-Key не более одного раза:
+// повтор с тем же ключом получает сохраненный ответ, повтор с другим телом - 409.
+// Ответы 5xx не сохраняются, чтобы запрос можно было повторить. Ключи хранятся для пользователя,
+// поэтому у анонимных запросов (гостевой корзины) заголовок не учитывается.
+// Должен подключаться после AuthMiddleware или OptionalAuth
+func Idempotency(idempotency service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		userID, ok := UserIDFromContext(c)
+		if key == "" || !ok {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		if err != nil {
+			abortWithError(c, domain.NewValidationError("request body is too large or unreadable"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		saved, err := idempotency.Begin(userID, key, requestFingerprint(c.Request, body))
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if saved != nil {
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(saved.StatusCode, saved.ContentType, saved.Body)
+			c.Abort()
+			return
+		}
+
+		// Если обработчик паникует, ключ освобождается, иначе повторы получали бы in_progress
+		// до истечения срока ключа. Панику обрабатывает Recovery снаружи
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if err := idempotency.Release(userID, key); err != nil {
+					log.Printf("%s %s: idempotency key %q: %v", c.Request.Method, c.Request.URL.Path, key, err)
+				}
+				panic(recovered)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		// Ошибку записываем здесь, а не в ErrorHandler, чтобы сохранить ее вместе с ответом
+		respondWithError(c)
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			err = idempotency.Release(userID, key)
+		} else {
+			err = idempotency.Complete(userID, key, status, c.Writer.Header().Get("Content-Type"), recorder.body.Bytes())
+		}
+		if err != nil {
+			log.Printf("%s %s: idempotency key %q: %v", c.Request.Method, c.Request.URL.Path, key, err)
+		}
+	}
+}
+
+// requestFingerprint вычисляет отпечаток запроса по методу, пути и телу
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder копирует тело ответа, чтобы его можно было сохранить
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// RequirePermission пропускает запрос, только если роль пользователя
+// имеет указанное право. Должен подключаться после AuthMiddleware
+func RequirePermission(permission domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := requireUserID(c); !ok {
+			return
+		}
+		if !RoleFromContext(c).Can(permission) {
+			abortWithError(c, domain.NewForbiddenError("insufficient permissions"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// UserIDFromContext возвращает идентификатор аутентифицированного пользователя
+func UserIDFromContext(c *gin.Context) (uint, bool) {
+	value, exists := c.Get(userIDKey)
+	if !exists {
+		return 0, false
+	}
+	userID, ok := value.(uint)
+	return userID, ok && userID != 0
+}
+
+// RoleFromContext возвращает роль аутентифицированного пользователя
+// Если роль не установлена, пользователь считается покупателем
+func RoleFromContext(c *gin.Context) domain.Role {
+	if value, exists := c.Get(roleKey); exists {
+		if role, ok := value.(domain.Role); ok {
+			return role
+		}
+	}
+	return domain.RoleCustomer
+}
+
+// requireCartOwner возвращает владельца корзины, определенного CartSession
+func requireCartOwner(c *gin.Context) (domain.CartOwner, bool) {
+	value, _ := c.Get(cartOwnerKey)
+	owner, ok := value.(domain.CartOwner)
+	if !ok {
+		abortWithError(c, domain.NewUnauthorizedError("cart session required"))
+		return domain.CartOwner{}, false
+	}
+	return owner, true
+}
+
+// requireUserID возвращает идентификатор пользователя или прерывает запрос
+// с ошибкой unauthorized, если он не прошел аутентификацию
+func requireUserID(c *gin.Context) (uint, bool) {
+	userID, ok := UserIDFromContext(c)
+	if !ok {
+		abortWithError(c, domain.NewUnauthorizedError("authentication required"))
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,372 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"shopping-cart/internal/auth"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/service"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockUserService - мок сервиса пользователей
+type mockUserService struct {
+	mock.Mock
+}
+
+func (m *mockUserService) Register(email, password, name string) (*domain.User, error) {
+	args := m.Called(email, password, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *mockUserService) Login(email, password string) (*domain.User, error) {
+	args := m.Called(email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *mockUserService) ChangePassword(userID uint, oldPassword, newPassword string) error {
+	args := m.Called(userID, oldPassword, newPassword)
+	return args.Error(0)
+}
+
+func (m *mockUserService) GetUser(id uint) (*domain.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *mockUserService) SetRole(userID uint, role domain.Role) error {
+	args := m.Called(userID, role)
+	return args.Error(0)
+}
+
+// newTestUsers возвращает сервис пользователей с покупателем 1, сотрудником 2,
+// сотрудником 3, пониженным до покупателя после выдачи токена, и удаленным пользователем 4
+func newTestUsers() *mockUserService {
+	users := new(mockUserService)
+	users.On("GetUser", uint(1)).Return(&domain.User{ID: 1, Role: domain.RoleCustomer}, nil)
+	users.On("GetUser", uint(2)).Return(&domain.User{ID: 2, Role: domain.RoleStaff}, nil)
+	users.On("GetUser", uint(3)).Return(&domain.User{ID: 3, Role: domain.RoleCustomer}, nil)
+	users.On("GetUser", uint(4)).Return(nil, domain.NewNotFoundError("user", nil))
+	return users
+}
+
+func TestAuthMiddlewareAndPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens := auth.NewTokenManager("secret", time.Hour)
+	users := newTestUsers()
+
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/me", AuthMiddleware(tokens, users), func(c *gin.Context) {
+		userID, _ := UserIDFromContext(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": userID})
+	})
+	router.POST("/products", AuthMiddleware(tokens, users), RequirePermission(domain.PermissionManageCatalog), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	customerToken, _ := tokens.Issue(1)
+	staffToken, _ := tokens.Issue(2)
+	demotedToken, _ := tokens.Issue(3)
+	deletedToken, _ := tokens.Issue(4)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			name:           "Без токена",
+			method:         http.MethodGet,
+			path:           "/me",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Невалидный токен",
+			method:         http.MethodGet,
+			path:           "/me",
+			authorization:  "Bearer garbage",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Валидный токен",
+			method:         http.MethodGet,
+			path:           "/me",
+			authorization:  "Bearer " + customerToken,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Покупатель не может менять каталог",
+			method:         http.MethodPost,
+			path:           "/products",
+			authorization:  "Bearer " + customerToken,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Сотрудник может менять каталог",
+			method:         http.MethodPost,
+			path:           "/products",
+			authorization:  "Bearer " + staffToken,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Пониженный сотрудник теряет права до истечения токена",
+			method:         http.MethodPost,
+			path:           "/products",
+			authorization:  "Bearer " + demotedToken,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Токен удаленного пользователя",
+			method:         http.MethodGet,
+			path:           "/me",
+			authorization:  "Bearer " + deletedToken,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+// mockIdempotencyService - мок сервиса ключей идемпотентности
+type mockIdempotencyService struct {
+	mock.Mock
+}
+
+func (m *mockIdempotencyService) Begin(userID uint, key string, fingerprint string) (*domain.IdempotencyKey, error) {
+	args := m.Called(userID, key, fingerprint)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.IdempotencyKey), args.Error(1)
+}
+
+func (m *mockIdempotencyService) Complete(userID uint, key string, statusCode int, contentType string, body []byte) error {
+	args := m.Called(userID, key, statusCode, contentType, body)
+	return args.Error(0)
+}
+
+func (m *mockIdempotencyService) Release(userID uint, key string) error {
+	args := m.Called(userID, key)
+	return args.Error(0)
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"shipping_address_id":1}`
+	saved := &domain.IdempotencyKey{StatusCode: http.StatusCreated, ContentType: "application/json; charset=utf-8", Body: []byte(`{"id":5}`)}
+
+	tests := []struct {
+		name           string
+		key            string
+		saved          *domain.IdempotencyKey
+		beginError     error
+		handlerError   error
+		handlerPanics  bool
+		expectHandler  bool
+		expectComplete int
+		expectRelease  bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "Без ключа", expectHandler: true, expectedStatus: http.StatusCreated, expectedBody: `{"id":7}`},
+		{name: "Первый запрос с ключом", key: "checkout-1", expectHandler: true, expectComplete: http.StatusCreated, expectedStatus: http.StatusCreated, expectedBody: `{"id":7}`},
+		{name: "Повтор получает исходный ответ", key: "checkout-1", saved: saved, expectedStatus: http.StatusCreated, expectedBody: `{"id":5}`},
+		{name: "Ключ использован с другим телом", key: "checkout-1", beginError: service.ErrIdempotencyKeyReused, expectedStatus: http.StatusConflict},
+		{
+			name:           "Ошибка обработчика сохраняется",
+			key:            "checkout-1",
+			handlerError:   domain.ErrEmptyCart,
+			expectHandler:  true,
+			expectComplete: http.StatusUnprocessableEntity,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Внутренняя ошибка освобождает ключ",
+			key:            "checkout-1",
+			handlerError:   errors.New("connection reset"),
+			expectHandler:  true,
+			expectRelease:  true,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Паника обработчика освобождает ключ",
+			key:            "checkout-1",
+			handlerPanics:  true,
+			expectHandler:  true,
+			expectRelease:  true,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idempotencyService := new(mockIdempotencyService)
+			idempotencyService.On("Begin", uint(1), tt.key, mock.Anything).Return(tt.saved, tt.beginError).Maybe()
+			idempotencyService.On("Complete", uint(1), tt.key, tt.expectComplete, mock.Anything, mock.Anything).Return(nil).Maybe()
+			idempotencyService.On("Release", uint(1), tt.key).Return(nil).Maybe()
+
+			handlerCalled := false
+			router := gin.New()
+			router.Use(gin.CustomRecoveryWithWriter(io.Discard, gin.RecoveryFunc(func(c *gin.Context, _ any) {
+				c.AbortWithStatus(http.StatusInternalServerError)
+			})), ErrorHandler())
+			router.POST("/orders", func(c *gin.Context) {
+				c.Set(userIDKey, uint(1))
+			}, Idempotency(idempotencyService), func(c *gin.Context) {
+				handlerCalled = true
+				data, _ := c.GetRawData()
+				assert.Equal(t, body, string(data))
+				if tt.handlerPanics {
+					panic("nil map write")
+				}
+				if tt.handlerError != nil {
+					abortWithError(c, tt.handlerError)
+					return
+				}
+				c.JSON(http.StatusCreated, gin.H{"id": 7})
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+			if tt.key != "" {
+				req.Header.Set(IdempotencyKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+			assert.Equal(t, tt.expectHandler, handlerCalled)
+			assert.Equal(t, tt.saved != nil, w.Header().Get(IdempotentReplayedHeader) == "true")
+
+			if tt.key == "" {
+				idempotencyService.AssertNotCalled(t, "Begin", mock.Anything, mock.Anything, mock.Anything)
+			}
+			if tt.expectComplete != 0 {
+				idempotencyService.AssertCalled(t, "Complete", uint(1), tt.key, tt.expectComplete, mock.Anything, w.Body.Bytes())
+			} else {
+				idempotencyService.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+			if tt.expectRelease {
+				idempotencyService.AssertCalled(t, "Release", uint(1), tt.key)
+			} else {
+				idempotencyService.AssertNotCalled(t, "Release", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestCartSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tokens := auth.NewTokenManager("secret", time.Hour)
+	customerToken, _ := tokens.Issue(1)
+	guestToken, _ := domain.NewCartToken()
+
+	tests := []struct {
+		name           string
+		method         string
+		authorization  string
+		header         string
+		cookie         string
+		expectedStatus int
+		expectedUserID uint
+		expectedToken  string
+		expectNewToken bool
+		expectNoToken  bool
+	}{
+		{name: "Пользователь работает со своей корзиной", authorization: "Bearer " + customerToken, header: guestToken, expectedStatus: http.StatusOK, expectedUserID: 1},
+		{name: "Невалидный токен пользователя", authorization: "Bearer garbage", expectedStatus: http.StatusUnauthorized},
+		{name: "Гость без токена получает новый", method: http.MethodPost, expectedStatus: http.StatusOK, expectNewToken: true},
+		{name: "Чтение без токена не выдает новый", expectedStatus: http.StatusOK, expectNoToken: true},
+		{name: "Гость с токеном в заголовке", header: guestToken, expectedStatus: http.StatusOK, expectedToken: guestToken},
+		{name: "Гость с токеном в cookie", cookie: guestToken, expectedStatus: http.StatusOK, expectedToken: guestToken},
+		{name: "Токен чужого формата заменяется", method: http.MethodPost, header: "guessed", expectedStatus: http.StatusOK, expectNewToken: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var owner domain.CartOwner
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.Any("/cart", OptionalAuth(AuthMiddleware(tokens, newTestUsers())), CartSession(), func(c *gin.Context) {
+				owner, _ = requireCartOwner(c)
+				c.Status(http.StatusOK)
+			})
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/cart", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.header != "" {
+				req.Header.Set(CartTokenHeader, tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: cartTokenCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.expectedUserID, owner.UserID)
+			if tt.expectedUserID != 0 {
+				assert.Empty(t, owner.GuestToken)
+				assert.Empty(t, w.Header().Get(CartTokenHeader))
+				return
+			}
+			if tt.expectNoToken {
+				assert.Empty(t, owner.GuestToken)
+				assert.Empty(t, w.Header().Get(CartTokenHeader))
+				assert.Empty(t, w.Header().Get("Set-Cookie"))
+				return
+			}
+
+			issued := w.Header().Get(CartTokenHeader)
+			assert.Equal(t, owner.GuestToken, issued)
+			assert.Contains(t, w.Header().Get("Set-Cookie"), cartTokenCookie+"="+issued)
+			if tt.expectNewToken {
+				assert.True(t, domain.IsCartToken(issued))
+				assert.NotEqual(t, tt.header, issued)
+			} else {
+				assert.Equal(t, tt.expectedToken, issued)
+			}
+		})
+	}
+}
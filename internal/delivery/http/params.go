@@ -0,0 +1,20 @@
+package http
+
+import (
+	"fmt"
+	"shopping-cart/internal/domain"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseIDParam извлекает идентификатор из параметра пути
+// При отсутствии или некорректном значении прерывает запрос с ошибкой валидации
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, strconv.IntSize)
+	if err != nil || id == 0 {
+		abortWithError(c, domain.NewValidationError(fmt.Sprintf("invalid %s: must be a positive integer", name)))
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+	"shopping-cart/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Оплатить заказ
+// @Description Авторизует и списывает сумму заказа через платежный шлюз, при успехе заказ переходит в статус paid
+// @Tags order
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 201 {object} domain.Payment
+// @Failure 400 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 504 {object} ErrorResponse
+// @Router /orders/{id}/pay [post]
+func (h *Handler) PayOrder(c *gin.Context) {
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Source string `json:"source" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	payment, err := h.paymentService.PayOrder(userID, orderID, request.Source)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, payment)
+}
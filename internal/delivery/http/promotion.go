@@ -0,0 +1,89 @@
+package http
+
+import (
+	"net/http"
+	"shopping-cart/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Применить купон
+// @Description Применяет купон к корзине пользователя и возвращает пересчитанную корзину
+// @Tags cart
+// @Security BearerAuth
+// @Param X-Cart-Token header string false "Токен гостевой корзины"
+// @Accept json
+// @Produce json
+// @Success 200 {object} domain.Cart
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /cart/coupon [post]
+func (h *Handler) ApplyCoupon(c *gin.Context) {
+	var request struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	owner, ok := requireCartOwner(c)
+	if !ok {
+		return
+	}
+	cart, err := h.cartService.ApplyCoupon(owner, request.Code)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cart)
+}
+
+// RemoveCoupon снимает купон с корзины
+func (h *Handler) RemoveCoupon(c *gin.Context) {
+	owner, ok := requireCartOwner(c)
+	if !ok {
+		return
+	}
+	if err := h.cartService.RemoveCoupon(owner); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Создать акцию
+// @Description Создает акцию с кодом купона
+// @Tags promotion
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param promotion body domain.Promotion true "Акция"
+// @Success 201 {object} domain.Promotion
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /promotions [post]
+func (h *Handler) CreatePromotion(c *gin.Context) {
+	var promotion domain.Promotion
+	if err := c.ShouldBindJSON(&promotion); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	if err := h.promotionService.CreatePromotion(&promotion); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, promotion)
+}
+
+// GetAllPromotions возвращает список акций
+func (h *Handler) GetAllPromotions(c *gin.Context) {
+	promotions, err := h.promotionService.GetAllPromotions()
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, promotions)
+}
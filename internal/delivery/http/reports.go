@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+	"shopping-cart/internal/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Брошенные корзины
+// @Description Возвращает корзины с товарами, которые не менялись дольше idle_hours часов, начиная с самых дорогих (staff, admin)
+// @Tags reports
+// @Security BearerAuth
+// @Produce json
+// @Param idle_hours query int true "Сколько часов корзину не меняли"
+// @Param limit query int false "Количество строк отчета (1-500, по умолчанию 100)"
+// @Success 200 {array} domain.AbandonedCart
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /reports/abandoned-carts [get]
+func (h *Handler) GetAbandonedCarts(c *gin.Context) {
+	var request struct {
+		IdleHours int `form:"idle_hours" binding:"required,min=1"`
+		Limit     int `form:"limit,default=100"`
+	}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	report, err := h.cartExpiryService.GetAbandonedCarts(time.Duration(request.IdleHours)*time.Hour, request.Limit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
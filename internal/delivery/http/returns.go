@@ -0,0 +1,135 @@
+package http
+
+import (
+	"net/http"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Оформить возврат
+// @Description Создает заявку на возврат позиций доставленного заказа
+// @Tags returns
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 201 {object} domain.OrderReturn
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /orders/{id}/returns [post]
+func (h *Handler) RequestReturn(c *gin.Context) {
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+		Items  []struct {
+			OrderItemID uint `json:"order_item_id" binding:"required"`
+			Quantity    int  `json:"quantity" binding:"required,min=1"`
+		} `json:"items" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	lines := make([]service.ReturnLine, 0, len(request.Items))
+	for _, item := range request.Items {
+		lines = append(lines, service.ReturnLine{OrderItemID: item.OrderItemID, Quantity: item.Quantity})
+	}
+	orderReturn, err := h.returnService.RequestReturn(userID, orderID, request.Reason, lines)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, orderReturn)
+}
+
+// GetOrderReturns возвращает заявки на возврат по заказу
+func (h *Handler) GetOrderReturns(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	orderID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	returns, err := h.returnService.GetOrderReturns(userID, RoleFromContext(c), orderID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, returns)
+}
+
+// @Summary Одобрить возврат
+// @Description Возвращает деньги и товары на склад, заказ переходит в partially_refunded или refunded
+// @Tags returns
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заявки на возврат"
+// @Success 200 {object} domain.OrderReturn
+// @Failure 402 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /returns/{id}/approve [post]
+func (h *Handler) ApproveReturn(c *gin.Context) {
+	h.reviewReturn(c, h.returnService.ApproveReturn)
+}
+
+// @Summary Отклонить возврат
+// @Description Отклоняет заявку на возврат, причина обязательна
+// @Tags returns
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заявки на возврат"
+// @Success 200 {object} domain.OrderReturn
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /returns/{id}/reject [post]
+func (h *Handler) RejectReturn(c *gin.Context) {
+	h.reviewReturn(c, h.returnService.RejectReturn)
+}
+
+// reviewReturn разбирает запрос с решением по заявке и передает его в decide
+func (h *Handler) reviewReturn(c *gin.Context, decide func(actorID uint, returnID uint, note string) (*domain.OrderReturn, error)) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	returnID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Note string `json:"note"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			abortWithError(c, domain.NewValidationError(err.Error()))
+			return
+		}
+	}
+
+	orderReturn, err := decide(userID, returnID, request.Note)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, orderReturn)
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Вебхук событий заказа
+// @Description Принимает подписанное событие платежного провайдера или службы доставки и меняет статус заказа
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Param X-Webhook-Signature header string true "Подпись t=<unix-время>,v1=<HMAC-SHA256>"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /webhooks/orders [post]
+func (h *Handler) HandleOrderWebhook(c *gin.Context) {
+	var request struct {
+		ID      string `json:"id" binding:"required"`
+		Type    string `json:"type" binding:"required"`
+		OrderID uint   `json:"order_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+
+	processed, err := h.webhookService.HandleOrderEvent(service.OrderEvent{
+		ID:      request.ID,
+		Type:    request.Type,
+		OrderID: request.OrderID,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	// Повторная доставка подтверждается тем же 200, чтобы отправитель прекратил повторы
+	status := "processed"
+	if !processed {
+		status = "duplicate"
+	}
+	c.JSON(http.StatusOK, gin.H{"status": status})
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"shopping-cart/internal/service"
+	"shopping-cart/internal/webhook"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockWebhookService - мок сервиса вебхуков
+type mockWebhookService struct {
+	mock.Mock
+}
+
+func (m *mockWebhookService) HandleOrderEvent(event service.OrderEvent) (bool, error) {
+	args := m.Called(event)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestHandleOrderWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := webhook.NewSigner("webhook-secret", 5*time.Minute)
+	payload := []byte(`{"id":"evt_1","type":"payment.succeeded","order_id":5}`)
+	event := service.OrderEvent{ID: "evt_1", Type: "payment.succeeded", OrderID: 5}
+
+	tests := []struct {
+		name           string
+		payload        []byte
+		signature      string
+		processed      bool
+		expectCall     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Подписанное событие",
+			payload:        payload,
+			signature:      signer.Sign(payload, time.Now()),
+			processed:      true,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "processed",
+		},
+		{
+			name:           "Повторная доставка",
+			payload:        payload,
+			signature:      signer.Sign(payload, time.Now()),
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "duplicate",
+		},
+		{
+			name:           "Без подписи",
+			payload:        payload,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Подпись другого тела",
+			payload:        []byte(`{"id":"evt_1","type":"payment.refunded","order_id":5}`),
+			signature:      signer.Sign(payload, time.Now()),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Перехваченный старый запрос",
+			payload:        payload,
+			signature:      signer.Sign(payload, time.Now().Add(-time.Hour)),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Подписанное тело без обязательных полей",
+			payload:        []byte(`{"id":"evt_2"}`),
+			signature:      signer.Sign([]byte(`{"id":"evt_2"}`), time.Now()),
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhookService := new(mockWebhookService)
+			webhookService.On("HandleOrderEvent", event).Return(tt.processed, nil).Maybe()
+
+			handler := &Handler{webhookService: webhookService, webhookMiddleware: WebhookSignature(signer)}
+			router := gin.New()
+			router.Use(ErrorHandler())
+			handler.RegisterRoutes(router)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/webhooks/orders", bytes.NewReader(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.signature != "" {
+				req.Header.Set(webhook.SignatureHeader, tt.signature)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				var body map[string]string
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.expectedBody, body["status"])
+			}
+			if tt.expectCall {
+				webhookService.AssertExpectations(t)
+			} else {
+				webhookService.AssertNotCalled(t, "HandleOrderEvent", mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package http
+
+import (
+	"net/http"
+	"shopping-cart/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Получить список отложенных товаров
+// @Description Возвращает список отложенных товаров текущего пользователя
+// @Tags wishlist
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.Wishlist
+// @Failure 401 {object} ErrorResponse
+// @Router /wishlist [get]
+func (h *Handler) GetWishlist(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	wishlist, err := h.wishlistService.GetWishlist(userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// @Summary Отложить товар
+// @Description Добавляет товар из каталога в список отложенных, повторное добавление увеличивает количество
+// @Tags wishlist
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Success 201 {object} domain.SavedItem
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /wishlist/items [post]
+func (h *Handler) SaveProduct(c *gin.Context) {
+	var request struct {
+		ProductID uint `json:"product_id" binding:"required"`
+		Quantity  int  `json:"quantity" binding:"omitempty,min=1"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		abortWithError(c, domain.NewValidationError(err.Error()))
+		return
+	}
+	if request.Quantity == 0 {
+		request.Quantity = 1
+	}
+
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	item, err := h.wishlistService.SaveProduct(userID, request.ProductID, request.Quantity)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, item)
+}
+
+// @Summary Удалить отложенный товар
+// @Description Удаляет товар из списка отложенных
+// @Tags wishlist
+// @Security BearerAuth
+// @Param id path int true "ID отложенного товара"
+// @Success 204
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /wishlist/items/{id} [delete]
+func (h *Handler) RemoveSavedItem(c *gin.Context) {
+	itemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.wishlistService.RemoveSavedItem(userID, itemID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Отложить позицию корзины
+// @Description Переносит позицию корзины в список отложенных вместе с количеством
+// @Tags cart
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID элемента корзины"
+// @Success 201 {object} domain.SavedItem
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /cart/items/{id}/save [post]
+func (h *Handler) SaveCartItem(c *gin.Context) {
+	itemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	item, err := h.wishlistService.SaveCartItem(userID, itemID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, item)
+}
+
+// @Summary Вернуть отложенный товар в корзину
+// @Description Переносит отложенный товар в корзину по текущей цене с проверкой остатков
+// @Tags wishlist
+// @Security BearerAuth
+// @Param id path int true "ID отложенного товара"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /wishlist/items/{id}/move-to-cart [post]
+func (h *Handler) MoveSavedItemToCart(c *gin.Context) {
+	itemID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.wishlistService.MoveToCart(userID, itemID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Опубликовать список отложенных
+// @Description Открывает список для просмотра без входа по случайному адресу slug
+// @Tags wishlist
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.Wishlist
+// @Failure 401 {object} ErrorResponse
+// @Router /wishlist/share [post]
+func (h *Handler) ShareWishlist(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	wishlist, err := h.wishlistService.Share(userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// @Summary Снять публикацию списка отложенных
+// @Description Прежний адрес списка перестает открываться
+// @Tags wishlist
+// @Security BearerAuth
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Router /wishlist/share [delete]
+func (h *Handler) UnshareWishlist(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.wishlistService.Unshare(userID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Опубликованный список отложенных
+// @Description Возвращает опубликованный список для просмотра без входа
+// @Tags wishlist
+// @Produce json
+// @Param slug path string true "Адрес списка"
+// @Success 200 {object} domain.Wishlist
+// @Failure 404 {object} ErrorResponse
+// @Router /wishlists/{slug} [get]
+func (h *Handler) GetSharedWishlist(c *gin.Context) {
+	wishlist, err := h.wishlistService.GetSharedWishlist(c.Param("slug"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, wishlist)
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostalAddress - почтовый адрес
+// Используется в адресной книге пользователя и как снимок адреса в заказе,
+// поэтому обязательность полей проверяется в Validate, а не ограничениями таблицы
+type PostalAddress struct {
+	Recipient  string `json:"recipient"`
+	Phone      string `json:"phone"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	// Country - код страны ISO 3166-1 alpha-2, определяет зону доставки и налоговый регион
+	Country string `gorm:"type:char(2)" json:"country"`
+}
+
+// Address - адрес из адресной книги пользователя
+type Address struct {
+	ID            uint   `gorm:"primarykey" json:"id"`
+	UserID        uint   `gorm:"not null;index" json:"user_id"`
+	User          *User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	Label         string `json:"label"`
+	PostalAddress `gorm:"embedded"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Normalize убирает лишние пробелы и приводит код страны к верхнему регистру
+func (a *PostalAddress) Normalize() {
+	for _, field := range []*string{&a.Recipient, &a.Phone, &a.Line1, &a.Line2, &a.City, &a.Region, &a.PostalCode} {
+		*field = strings.TrimSpace(*field)
+	}
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+}
+
+// Validate проверяет, что заполнены обязательные поля адреса
+func (a *PostalAddress) Validate() error {
+	if a.Recipient == "" || a.Line1 == "" || a.City == "" || a.PostalCode == "" {
+		return NewValidationError("recipient, line1, city and postal_code are required")
+	}
+	if len(a.Country) != 2 {
+		return NewValidationError("country must be a two-letter ISO code")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// MaxLineQuantity - максимальное количество единиц одного товара в позиции корзины
+const MaxLineQuantity = 99
+
+// cartTokenBytes - длина токена гостевой корзины в байтах до кодирования в hex
+const cartTokenBytes = 32
+
+// CartOwner - владелец корзины: зарегистрированный пользователь
+// или гость, которого узнают по токену корзины
+type CartOwner struct {
+	UserID     uint
+	GuestToken string
+}
+
+// IsGuest проверяет, что корзина принадлежит неаутентифицированному посетителю
+func (o CartOwner) IsGuest() bool {
+	return o.UserID == 0
+}
+
+// AbandonedCart - строка отчета о брошенной корзине: корзина с товарами,
+// которую давно не меняли. Value считается по ценам, сохраненным в позициях
+type AbandonedCart struct {
+	CartID         uint      `json:"cart_id"`
+	UserID         *uint     `json:"user_id,omitempty"`
+	Email          string    `json:"email,omitempty"`
+	ItemCount      int       `json:"item_count"`
+	Value          Money     `gorm:"embedded;embeddedPrefix:value_" json:"value"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+}
+
+// Owner возвращает владельца корзины
+func (c *Cart) Owner() CartOwner {
+	var owner CartOwner
+	if c.UserID != nil {
+		owner.UserID = *c.UserID
+	}
+	if c.GuestToken != nil {
+		owner.GuestToken = *c.GuestToken
+	}
+	return owner
+}
+
+// NewCartToken создает случайный токен гостевой корзины
+func NewCartToken() (string, error) {
+	token := make([]byte, cartTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("generate cart token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// IsCartToken проверяет, что строка имеет формат токена, выданного NewCartToken
+func IsCartToken(token string) bool {
+	if len(token) != hex.EncodedLen(cartTokenBytes) {
+		return false
+	}
+	_, err := hex.DecodeString(token)
+	return err == nil
+}
+
+// ValidateLineQuantity проверяет количество товара в позиции корзины
+func ValidateLineQuantity(quantity int) error {
+	if quantity <= 0 {
+		return NewValidationError("quantity must be positive")
+	}
+	if quantity > MaxLineQuantity {
+		return NewValidationError(fmt.Sprintf("quantity must not exceed %d", MaxLineQuantity))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCartToken(t *testing.T) {
+	token, err := NewCartToken()
+	assert.NoError(t, err)
+	other, err := NewCartToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other)
+
+	tests := []struct {
+		name     string
+		token    string
+		expected bool
+	}{
+		{name: "Выданный токен", token: token, expected: true},
+		{name: "Пустой токен", token: ""},
+		{name: "Короткий токен", token: token[:32]},
+		{name: "Не hex", token: strings.Repeat("z", len(token))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsCartToken(tt.token))
+		})
+	}
+}
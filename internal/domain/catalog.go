@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProductSort - порядок товаров в каталоге
+type ProductSort string
+
+const (
+	// ProductSortNewest - сначала новые товары
+	ProductSortNewest ProductSort = "newest"
+	// ProductSortPriceAsc - по возрастанию цены
+	ProductSortPriceAsc ProductSort = "price_asc"
+	// ProductSortPriceDesc - по убыванию цены
+	ProductSortPriceDesc ProductSort = "price_desc"
+	// ProductSortName - по названию
+	ProductSortName ProductSort = "name"
+)
+
+const (
+	// DefaultProductPageSize - размер страницы каталога по умолчанию
+	DefaultProductPageSize = 20
+	// MaxProductPageSize - наибольший размер страницы каталога
+	MaxProductPageSize = 100
+)
+
+// IsValid проверяет, что порядок сортировки известен
+func (s ProductSort) IsValid() bool {
+	switch s {
+	case ProductSortNewest, ProductSortPriceAsc, ProductSortPriceDesc, ProductSortName:
+		return true
+	}
+	return false
+}
+
+// ProductFilter - условия отбора товаров каталога
+// Пустые поля не ограничивают выборку. Name ищется как подстрока без учета регистра
+type ProductFilter struct {
+	Name     string
+	Category string
+	MinPrice *Money
+	MaxPrice *Money
+	InStock  bool
+}
+
+// ProductQuery - запрос страницы каталога
+// Страница задается либо смещением Offset, либо курсором After, но не обоими сразу
+type ProductQuery struct {
+	ProductFilter
+	Sort   ProductSort
+	Limit  int
+	Offset int
+	After  *ProductCursor
+}
+
+// Normalize подставляет значения по умолчанию и проверяет запрос
+func (q *ProductQuery) Normalize() error {
+	q.Name = strings.TrimSpace(q.Name)
+	q.Category = strings.TrimSpace(q.Category)
+
+	if q.Sort == "" && q.After != nil {
+		q.Sort = q.After.Sort
+	}
+	if q.Sort == "" {
+		q.Sort = ProductSortNewest
+	}
+	if !q.Sort.IsValid() {
+		return NewValidationError(fmt.Sprintf("unknown sort %q", q.Sort))
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultProductPageSize
+	}
+	if q.Limit < 0 || q.Limit > MaxProductPageSize {
+		return NewValidationError(fmt.Sprintf("limit must be between 1 and %d", MaxProductPageSize))
+	}
+	if q.Offset < 0 {
+		return NewValidationError("offset must not be negative")
+	}
+	if q.After != nil {
+		if q.Offset > 0 {
+			return NewValidationError("cursor and offset cannot be used together")
+		}
+		if q.After.Sort != q.Sort {
+			return NewValidationError("cursor does not match sort")
+		}
+	}
+
+	for _, price := range []*Money{q.MinPrice, q.MaxPrice} {
+		if price != nil && price.IsNegative() {
+			return NewValidationError("price filter must not be negative")
+		}
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil {
+		if q.MinPrice.Currency != q.MaxPrice.Currency {
+			return NewValidationError("price filters must use the same currency")
+		}
+		if q.MinPrice.Amount > q.MaxPrice.Amount {
+			return NewValidationError("min_price must not exceed max_price")
+		}
+	}
+	return nil
+}
+
+// ProductCursor - позиция последнего товара страницы при выбранной сортировке
+// Следующая страница начинается сразу после нее, поэтому добавление и удаление товаров
+// не сдвигает страницы, как при пагинации смещением
+type ProductCursor struct {
+	Sort      ProductSort `json:"s"`
+	ID        uint        `json:"id"`
+	Price     int64       `json:"p,omitempty"`
+	Name      string      `json:"n,omitempty"`
+	CreatedAt time.Time   `json:"c"`
+}
+
+// NewProductCursor запоминает позицию товара при сортировке sort
+func NewProductCursor(sort ProductSort, product *Product) ProductCursor {
+	cursor := ProductCursor{Sort: sort, ID: product.ID}
+	switch sort {
+	case ProductSortPriceAsc, ProductSortPriceDesc:
+		cursor.Price = product.Price.Amount
+	case ProductSortName:
+		cursor.Name = product.Name
+	default:
+		cursor.CreatedAt = product.CreatedAt
+	}
+	return cursor
+}
+
+// Encode кодирует курсор в строку для передачи клиенту
+func (c ProductCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseProductCursor разбирает курсор, выданный Encode
+func ParseProductCursor(value string) (*ProductCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, NewValidationError("invalid cursor")
+	}
+	var cursor ProductCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 || !cursor.Sort.IsValid() {
+		return nil, NewValidationError("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// ProductPage - страница каталога
+// Total - число товаров, подходящих под фильтр, без учета пагинации.
+// NextCursor пуст на последней странице
+type ProductPage struct {
+	Items      []Product `json:"items"`
+	Total      int64     `json:"total"`
+	Limit      int       `json:"limit"`
+	Offset     int       `json:"offset"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
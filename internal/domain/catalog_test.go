@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductQueryNormalize(t *testing.T) {
+	minPrice := NewMoney(50000, "RUB")
+	maxPrice := NewMoney(10000, "RUB")
+	negative := NewMoney(-100, "RUB")
+	dollars := NewMoney(10000, "USD")
+	cursor := &ProductCursor{Sort: ProductSortPriceAsc, ID: 3, Price: 10000}
+
+	tests := []struct {
+		name          string
+		query         ProductQuery
+		expectedSort  ProductSort
+		expectedLimit int
+		expectedError error
+	}{
+		{name: "Значения по умолчанию", expectedSort: ProductSortNewest, expectedLimit: DefaultProductPageSize},
+		{name: "Сортировка берется из курсора", query: ProductQuery{After: cursor, Limit: 5}, expectedSort: ProductSortPriceAsc, expectedLimit: 5},
+		{name: "Неизвестная сортировка", query: ProductQuery{Sort: "rating"}, expectedError: ErrValidation},
+		{name: "Слишком большая страница", query: ProductQuery{Limit: MaxProductPageSize + 1}, expectedError: ErrValidation},
+		{name: "Отрицательное смещение", query: ProductQuery{Offset: -1}, expectedError: ErrValidation},
+		{name: "Курсор вместе со смещением", query: ProductQuery{After: cursor, Offset: 20}, expectedError: ErrValidation},
+		{name: "Курсор другой сортировки", query: ProductQuery{After: cursor, Sort: ProductSortName}, expectedError: ErrValidation},
+		{name: "Отрицательная цена", query: ProductQuery{ProductFilter: ProductFilter{MinPrice: &negative}}, expectedError: ErrValidation},
+		{name: "Минимальная цена больше максимальной", query: ProductQuery{ProductFilter: ProductFilter{MinPrice: &minPrice, MaxPrice: &maxPrice}}, expectedError: ErrValidation},
+		{name: "Цены в разных валютах", query: ProductQuery{ProductFilter: ProductFilter{MinPrice: &maxPrice, MaxPrice: &dollars}}, expectedError: ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Normalize()
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedSort, tt.query.Sort)
+			assert.Equal(t, tt.expectedLimit, tt.query.Limit)
+		})
+	}
+}
+
+func TestProductCursor(t *testing.T) {
+	product := &Product{ID: 7, Name: "Чайник", Price: NewMoney(199900, "RUB"), CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)}
+
+	for _, sort := range []ProductSort{ProductSortNewest, ProductSortPriceAsc, ProductSortPriceDesc, ProductSortName} {
+		t.Run(string(sort), func(t *testing.T) {
+			cursor := NewProductCursor(sort, product)
+			parsed, err := ParseProductCursor(cursor.Encode())
+			assert.NoError(t, err)
+			assert.Equal(t, sort, parsed.Sort)
+			assert.Equal(t, uint(7), parsed.ID)
+			assert.True(t, cursor.CreatedAt.Equal(parsed.CreatedAt))
+			assert.Equal(t, cursor.Price, parsed.Price)
+			assert.Equal(t, cursor.Name, parsed.Name)
+		})
+	}
+
+	for _, value := range []string{"", "not base64!", "e30", "eyJzIjoicmF0aW5nIiwiaWQiOjF9"} {
+		_, err := ParseProductCursor(value)
+		assert.ErrorIs(t, err, ErrValidation, value)
+	}
+}
@@ -0,0 +1,141 @@
+package domain
+
+import "fmt"
+
+// ErrorCode - машиночитаемый код ошибки, который получает клиент
+type ErrorCode string
+
+const (
+	CodeNotFound          ErrorCode = "not_found"
+	CodeConflict          ErrorCode = "conflict"
+	CodeValidation        ErrorCode = "validation_error"
+	CodeUnauthorized      ErrorCode = "unauthorized"
+	CodeForbidden         ErrorCode = "forbidden"
+	CodeEmptyCart         ErrorCode = "empty_cart"
+	CodeInsufficientStock ErrorCode = "insufficient_stock"
+	CodePaymentDeclined   ErrorCode = "payment_declined"
+	CodePaymentTimeout    ErrorCode = "payment_timeout"
+	CodePriceChanged      ErrorCode = "price_changed"
+	CodeInternal          ErrorCode = "internal_error"
+)
+
+// Error - ошибка предметной области
+// Message безопасно показывать клиенту, Err хранит исходную причину для логов
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Details map[string]any
+	Err     error
+}
+
+// Общие ошибки по кодам. Сравнение через errors.Is проверяет только код,
+// поэтому errors.Is(NewNotFoundError("product"), ErrNotFound) == true
+var (
+	ErrNotFound          = &Error{Code: CodeNotFound}
+	ErrConflict          = &Error{Code: CodeConflict}
+	ErrValidation        = &Error{Code: CodeValidation}
+	ErrUnauthorized      = &Error{Code: CodeUnauthorized}
+	ErrForbidden         = &Error{Code: CodeForbidden}
+	ErrInsufficientStock = &Error{Code: CodeInsufficientStock}
+	ErrPaymentDeclined   = &Error{Code: CodePaymentDeclined}
+	ErrPaymentTimeout    = &Error{Code: CodePaymentTimeout}
+	ErrPriceChanged      = &Error{Code: CodePriceChanged}
+
+	// ErrEmptyCart возвращается при попытке оформить заказ из пустой корзины
+	ErrEmptyCart = &Error{Code: CodeEmptyCart, Message: "cart is empty"}
+)
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = string(e.Code)
+	}
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is сравнивает ошибку с общими ошибками по коду
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Message == "" && t.Code == e.Code
+}
+
+// NewNotFoundError создает ошибку "ресурс не найден"
+func NewNotFoundError(resource string, err error) *Error {
+	return &Error{Code: CodeNotFound, Message: resource + " not found", Err: err}
+}
+
+// NewConflictError создает ошибку конфликта состояния
+func NewConflictError(message string) *Error {
+	return &Error{Code: CodeConflict, Message: message}
+}
+
+// NewValidationError создает ошибку валидации входных данных
+func NewValidationError(message string) *Error {
+	return &Error{Code: CodeValidation, Message: message}
+}
+
+// NewUnauthorizedError создает ошибку отсутствующей или неверной аутентификации
+func NewUnauthorizedError(message string) *Error {
+	return &Error{Code: CodeUnauthorized, Message: message}
+}
+
+// NewForbiddenError создает ошибку отказа в доступе
+func NewForbiddenError(message string) *Error {
+	return &Error{Code: CodeForbidden, Message: message}
+}
+
+// NewPaymentDeclinedError создает ошибку отклоненного платежа
+func NewPaymentDeclinedError(err error) *Error {
+	return &Error{Code: CodePaymentDeclined, Message: "payment was declined", Err: err}
+}
+
+// NewPaymentTimeoutError создает ошибку платежного шлюза, не ответившего вовремя
+func NewPaymentTimeoutError(err error) *Error {
+	return &Error{Code: CodePaymentTimeout, Message: "payment gateway did not respond, try again later", Err: err}
+}
+
+// StockShortage описывает позицию, которую нельзя продать в запрошенном количестве
+type StockShortage struct {
+	ProductID uint `json:"product_id"`
+	Requested int  `json:"requested"`
+	Available int  `json:"available"`
+}
+
+// NewInsufficientStockError создает ошибку нехватки товара на складе
+// со списком всех позиций, которых не хватает
+func NewInsufficientStockError(shortages ...StockShortage) *Error {
+	message := "insufficient stock"
+	if len(shortages) == 1 {
+		message = fmt.Sprintf("insufficient stock for product %d", shortages[0].ProductID)
+	}
+	return &Error{
+		Code:    CodeInsufficientStock,
+		Message: message,
+		Details: map[string]any{"lines": shortages},
+	}
+}
+
+// PriceChange описывает позицию корзины, цена товара в которой изменилась после добавления
+type PriceChange struct {
+	ItemID    uint  `json:"item_id"`
+	ProductID uint  `json:"product_id"`
+	OldPrice  Money `json:"old_price"`
+	NewPrice  Money `json:"new_price"`
+}
+
+// NewPriceChangedError создает ошибку изменившихся цен в корзине
+// со списком всех позиций, цены которых нужно подтвердить
+func NewPriceChangedError(changes ...PriceChange) *Error {
+	return &Error{
+		Code:    CodePriceChanged,
+		Message: "prices of some cart items have changed, confirm the new prices to place the order",
+		Details: map[string]any{"lines": changes},
+	}
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorIs(t *testing.T) {
+	cause := errors.New("record not found")
+	err := fmt.Errorf("get product: %w", NewNotFoundError("product", cause))
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, ErrEmptyCart, ErrEmptyCart)
+	assert.NotErrorIs(t, NewConflictError("other"), NewConflictError("another"))
+
+	var domainErr *Error
+	assert.True(t, errors.As(err, &domainErr))
+	assert.Equal(t, "product not found", domainErr.Message)
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// IdempotencyKey - ключ идемпотентности запроса пользователя и сохраненный ответ на него
+// Fingerprint - хеш метода, пути и тела запроса: повтор с тем же ключом, но другим
+// запросом отклоняется. StatusCode равен 0, пока первый запрос еще выполняется
+type IdempotencyKey struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key" json:"user_id"`
+	Key         string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_user_key" json:"key"`
+	Fingerprint string    `gorm:"type:varchar(64);not null" json:"fingerprint"`
+	StatusCode  int       `gorm:"not null;default:0" json:"status_code"`
+	ContentType string    `gorm:"type:varchar(128)" json:"content_type"`
+	Body        []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// IsCompleted проверяет, что ответ на первый запрос уже сохранен
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.StatusCode != 0
+}
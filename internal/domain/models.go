@@ -6,34 +6,59 @@ import (
 	"gorm.io/gorm"
 )
 
+// User представляет зарегистрированного пользователя
+type User struct {
+	ID           uint           `gorm:"primarykey" json:"id"`
+	Email        string         `gorm:"uniqueIndex;not null" json:"email"`
+	Name         string         `json:"name"`
+	Role         Role           `gorm:"type:varchar(20);not null;default:customer" json:"role"`
+	PasswordHash string         `gorm:"not null" json:"-"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
 // Product представляет товар в магазине
 type Product struct {
 	ID          uint           `gorm:"primarykey" json:"id"`
 	Name        string         `json:"name"`
 	Description string         `json:"description"`
-	Price       float64        `json:"price"`
+	Price       Money          `gorm:"embedded;embeddedPrefix:price_" json:"price"`
+	Stock       int            `gorm:"not null;default:0;check:stock >= 0" json:"stock"`
+	Category    string         `gorm:"type:varchar(64);not null;default:'';index:idx_products_category,where:deleted_at IS NULL" json:"category"`
+	TaxCategory string         `gorm:"type:varchar(32);not null;default:standard" json:"tax_category"`
+	WeightGrams int            `gorm:"not null;default:0;check:weight_grams >= 0" json:"weight_grams"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
-// Cart представляет корзину пользователя
+// Cart представляет корзину пользователя или гостя
+// У пользователя не больше одной корзины, не считая удаленных. Гостевая корзина
+// не привязана к пользователю, ее находят по токену GuestToken
 type Cart struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	UserID    uint           `json:"user_id"`
-	Items     []CartItem     `gorm:"foreignKey:CartID;constraint:OnDelete:CASCADE;" json:"items"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID         uint           `gorm:"primarykey" json:"id"`
+	UserID     *uint          `gorm:"uniqueIndex:idx_carts_active_user,where:deleted_at IS NULL;check:chk_carts_owner,user_id IS NOT NULL OR guest_token IS NOT NULL" json:"user_id,omitempty"`
+	GuestToken *string        `gorm:"type:varchar(64);uniqueIndex:idx_carts_active_guest,where:deleted_at IS NULL" json:"-"`
+	User       *User          `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	Items      []CartItem     `gorm:"foreignKey:CartID;constraint:OnDelete:CASCADE;" json:"items"`
+	CouponCode string         `gorm:"type:varchar(64)" json:"coupon_code,omitempty"`
+	Summary    *CartSummary   `gorm:"-" json:"summary,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // CartItem представляет элемент корзины
+// Каждый товар занимает в корзине не больше одной позиции
+// Price - цена товара на момент добавления, с ней сверяется цена при оформлении заказа
 type CartItem struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
-	CartID    uint           `json:"cart_id"`
-	ProductID uint           `json:"product_id"`
+	CartID    uint           `gorm:"uniqueIndex:idx_cart_items_active_product,where:deleted_at IS NULL" json:"cart_id"`
+	ProductID uint           `gorm:"uniqueIndex:idx_cart_items_active_product,where:deleted_at IS NULL" json:"product_id"`
 	Product   Product        `gorm:"foreignKey:ProductID" json:"product"`
 	Quantity  int            `json:"quantity"`
+	Price     Money          `gorm:"embedded;embeddedPrefix:price_" json:"price"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -46,7 +71,10 @@ type OrderItem struct {
 	ProductID uint           `json:"product_id"`
 	Product   Product        `gorm:"foreignKey:ProductID" json:"product"`
 	Quantity  int            `json:"quantity"`
-	Price     float64        `json:"price"`
+	Price     Money          `gorm:"embedded;embeddedPrefix:price_" json:"price"`
+	Discount  Money          `gorm:"embedded;embeddedPrefix:discount_" json:"discount"`
+	TaxRate   int            `gorm:"not null;default:0" json:"tax_rate"`
+	Tax       Money          `gorm:"embedded;embeddedPrefix:tax_" json:"tax"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -54,12 +82,42 @@ type OrderItem struct {
 
 // Order представляет заказ пользователя
 type Order struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	UserID    uint           `json:"user_id"`
-	Status    string         `json:"status"`
-	Items     []OrderItem    `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE;" json:"items"`
-	Total     float64        `json:"total"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                 uint            `gorm:"primarykey" json:"id"`
+	UserID             uint            `gorm:"not null;index" json:"user_id"`
+	User               *User           `gorm:"foreignKey:UserID;constraint:OnDelete:RESTRICT;" json:"-"`
+	Status             OrderStatus     `gorm:"type:varchar(20);not null;default:pending;index" json:"status"`
+	Items              []OrderItem     `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE;" json:"items"`
+	Subtotal           Money           `gorm:"embedded;embeddedPrefix:subtotal_" json:"subtotal"`
+	Discounts          []OrderDiscount `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE;" json:"discounts"`
+	Discount           Money           `gorm:"embedded;embeddedPrefix:discount_" json:"discount"`
+	Tax                Money           `gorm:"embedded;embeddedPrefix:tax_" json:"tax"`
+	TaxInclusive       bool            `gorm:"not null;default:false" json:"tax_inclusive"`
+	ShippingMethod     string          `gorm:"type:varchar(32)" json:"shipping_method"`
+	Shipping           Money           `gorm:"embedded;embeddedPrefix:shipping_" json:"shipping"`
+	ShippingAddress    PostalAddress   `gorm:"embedded;embeddedPrefix:shipping_address_" json:"shipping_address"`
+	BillingAddress     PostalAddress   `gorm:"embedded;embeddedPrefix:billing_address_" json:"billing_address"`
+	Payments           []Payment       `gorm:"foreignKey:OrderID" json:"payments,omitempty"`
+	Refunds            []Refund        `gorm:"foreignKey:OrderID" json:"refunds,omitempty"`
+	CancellationReason string          `json:"cancellation_reason,omitempty"`
+	CancelledAt        *time.Time      `json:"cancelled_at,omitempty"`
+	Total              Money           `gorm:"embedded;embeddedPrefix:total_" json:"total"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt  `gorm:"index" json:"-"`
+}
+
+// OrderStatusHistory - запись журнала изменений статуса заказа
+type OrderStatusHistory struct {
+	ID         uint        `gorm:"primarykey" json:"id"`
+	OrderID    uint        `gorm:"not null;index" json:"order_id"`
+	Order      *Order      `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE;" json:"-"`
+	FromStatus OrderStatus `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus   OrderStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	ChangedBy  *uint       `json:"changed_by"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// TableName задает имя таблицы журнала статусов
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
 }
@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency - валюта, в которой хранятся цены, если она не указана явно
+const DefaultCurrency = "RUB"
+
+// minorUnitsExceptions - валюты, у которых количество знаков после запятой отличается от 2
+var minorUnitsExceptions = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"BHD": 3,
+	"KWD": 3,
+}
+
+// Money - денежная сумма в минимальных единицах валюты (копейках, центах)
+// Хранится как целое число, чтобы исключить ошибки округления float64
+type Money struct {
+	Amount   int64  `gorm:"not null;default:0"`
+	Currency string `gorm:"type:char(3);not null;default:'RUB'"`
+}
+
+// NewMoney создает сумму из минимальных единиц валюты
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney разбирает десятичную строку вида "19.99" в сумму без потери точности
+func ParseMoney(value, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if !isValidCurrency(currency) {
+		return Money{}, NewValidationError(fmt.Sprintf("invalid currency %q", currency))
+	}
+
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	digits := MinorUnits(currency)
+	if whole == "" || len(fraction) > digits || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, NewValidationError(fmt.Sprintf("invalid amount %q for %s", value, currency))
+	}
+	fraction += strings.Repeat("0", digits-len(fraction))
+
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, NewValidationError(fmt.Sprintf("invalid amount %q", value))
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Zero возвращает нулевую сумму в валюте
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// Add складывает суммы в одной валюте
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, NewValidationError(fmt.Sprintf("currency mismatch: %s and %s", m.Currency, other.Currency))
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Sub вычитает сумму в той же валюте
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Mul умножает сумму на целое количество
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// IsNegative сообщает, меньше ли сумма нуля
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// IsZero сообщает, равна ли сумма нулю
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// String форматирует сумму в десятичном виде, например "19.99"
+func (m Money) String() string {
+	digits := MinorUnits(m.Currency)
+	if digits == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	scale := int64(math.Pow10(digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, digits, amount%scale)
+}
+
+// moneyJSON - представление суммы в API: сумма передается десятичной строкой,
+// чтобы клиенты не теряли точность при разборе
+type moneyJSON struct {
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+}
+
+// MarshalJSON кодирует сумму как {"amount": "19.99", "currency": "RUB"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{Amount: m.String(), Currency: m.Currency})
+}
+
+// UnmarshalJSON принимает сумму строкой или числом: {"amount": "19.99"} или {"amount": 19.99}
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Amount == "" {
+		return NewValidationError("amount is required")
+	}
+
+	parsed, err := ParseMoney(raw.Amount.String(), raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// MinorUnits возвращает количество знаков после запятой для валюты
+func MinorUnits(currency string) int {
+	if digits, ok := minorUnitsExceptions[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+func isValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
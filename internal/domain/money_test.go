@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		currency    string
+		expected    Money
+		expectError bool
+	}{
+		{name: "Рубли с копейками", value: "19.99", currency: "RUB", expected: NewMoney(1999, "RUB")},
+		{name: "Целое число", value: "100", currency: "RUB", expected: NewMoney(10000, "RUB")},
+		{name: "Один знак после запятой", value: "0.1", currency: "USD", expected: NewMoney(10, "USD")},
+		{name: "Валюта по умолчанию", value: "5", currency: "", expected: NewMoney(500, DefaultCurrency)},
+		{name: "Валюта без дробной части", value: "1500", currency: "JPY", expected: NewMoney(1500, "JPY")},
+		{name: "Лишние знаки после запятой", value: "1.999", currency: "RUB", expectError: true},
+		{name: "Не число", value: "abc", currency: "RUB", expectError: true},
+		{name: "Неизвестный формат валюты", value: "1", currency: "RUBLES", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := ParseMoney(tt.value, tt.currency)
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrValidation)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, money)
+		})
+	}
+}
+
+func TestMoneyArithmeticIsExact(t *testing.T) {
+	// 0.1 + 0.2 во float64 дает 0.30000000000000004
+	total := Zero("RUB")
+	for _, value := range []string{"0.10", "0.20"} {
+		price, err := ParseMoney(value, "RUB")
+		assert.NoError(t, err)
+		total, err = total.Add(price)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "0.30", total.String())
+	assert.Equal(t, "-1.05", NewMoney(-105, "RUB").String())
+	assert.Equal(t, NewMoney(2997, "RUB"), NewMoney(999, "RUB").Mul(3))
+	diff, err := NewMoney(1000, "RUB").Sub(NewMoney(1, "RUB"))
+	assert.NoError(t, err)
+	assert.Equal(t, "9.99", diff.String())
+
+	_, err = NewMoney(100, "RUB").Add(NewMoney(100, "USD"))
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(1999, "RUB"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount": "19.99", "currency": "RUB"}`, string(data))
+
+	var fromString, fromNumber Money
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": "19.99", "currency": "RUB"}`), &fromString))
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": 19.99, "currency": "RUB"}`), &fromNumber))
+	assert.Equal(t, NewMoney(1999, "RUB"), fromString)
+	assert.Equal(t, fromString, fromNumber)
+}
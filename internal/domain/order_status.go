@@ -0,0 +1,73 @@
+package domain
+
+// OrderStatus определяет статус заказа
+type OrderStatus string
+
+const (
+	OrderStatusPending           OrderStatus = "pending"
+	OrderStatusPaid              OrderStatus = "paid"
+	OrderStatusProcessing        OrderStatus = "processing"
+	OrderStatusShipped           OrderStatus = "shipped"
+	OrderStatusDelivered         OrderStatus = "delivered"
+	OrderStatusCancelled         OrderStatus = "cancelled"
+	OrderStatusPartiallyRefunded OrderStatus = "partially_refunded"
+	OrderStatusRefunded          OrderStatus = "refunded"
+)
+
+// orderTransitions - переходы между статусами заказа, доступные при смене статуса
+// сотрудником или событием внешней системы. Заказ собирается только после оплаты.
+// В возвратные статусы заказ переводит только одобрение возврата (см. refundTransitions)
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:           {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:              {OrderStatusProcessing},
+	OrderStatusProcessing:        {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:           {OrderStatusDelivered},
+	OrderStatusDelivered:         {},
+	OrderStatusPartiallyRefunded: {},
+	OrderStatusCancelled:         {},
+	OrderStatusRefunded:          {},
+}
+
+// refundTransitions - переходы, которые выполняет одобрение возврата вместе
+// с возвратом товаров на склад и денег покупателю
+var refundTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusDelivered:         {OrderStatusPartiallyRefunded, OrderStatusRefunded},
+	OrderStatusPartiallyRefunded: {OrderStatusRefunded},
+}
+
+// IsCancellable проверяет, можно ли отменить заказ в этом статусе
+// Отменяется новый заказ или заказ в сборке; оплаченный заказ сначала передается в сборку
+func (s OrderStatus) IsCancellable() bool {
+	return s == OrderStatusPending || s == OrderStatusProcessing
+}
+
+// IsReturnable проверяет, можно ли оформить возврат товаров заказа в этом статусе
+func (s OrderStatus) IsReturnable() bool {
+	return s == OrderStatusDelivered || s == OrderStatusPartiallyRefunded
+}
+
+// IsValid проверяет, что статус известен системе
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+// CanTransitionTo проверяет, разрешен ли переход в статус next при смене статуса
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	return containsStatus(orderTransitions[s], next)
+}
+
+// CanRefundTo проверяет, может ли одобрение возврата перевести заказ в статус next
+func (s OrderStatus) CanRefundTo(next OrderStatus) bool {
+	return containsStatus(refundTransitions[s], next)
+}
+
+// containsStatus проверяет, есть ли статус status в списке
+func containsStatus(statuses []OrderStatus, status OrderStatus) bool {
+	for _, allowed := range statuses {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
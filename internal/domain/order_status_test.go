@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderStatusTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     OrderStatus
+		to       OrderStatus
+		expected bool
+	}{
+		{name: "Оплата нового заказа", from: OrderStatusPending, to: OrderStatusPaid, expected: true},
+		{name: "Сборка оплаченного заказа", from: OrderStatusPaid, to: OrderStatusProcessing, expected: true},
+		{name: "Отправка оплаченного заказа после сборки", from: OrderStatusProcessing, to: OrderStatusShipped, expected: true},
+		{name: "Сборка неоплаченного заказа запрещена", from: OrderStatusPending, to: OrderStatusProcessing},
+		{name: "Возврат оплаченного заказа только через возврат денег", from: OrderStatusPaid, to: OrderStatusRefunded},
+		{name: "Возврат доставленного заказа только через заявку", from: OrderStatusDelivered, to: OrderStatusRefunded},
+		{name: "Частичный возврат только через заявку", from: OrderStatusDelivered, to: OrderStatusPartiallyRefunded},
+		{name: "Возврат остатка только через заявку", from: OrderStatusPartiallyRefunded, to: OrderStatusRefunded},
+		{name: "Частичный возврат неотправленного заказа запрещен", from: OrderStatusPaid, to: OrderStatusPartiallyRefunded},
+		{name: "Отмена отправленного заказа запрещена", from: OrderStatusShipped, to: OrderStatusCancelled},
+		{name: "Отмена оплаченного заказа до сборки запрещена", from: OrderStatusPaid, to: OrderStatusCancelled},
+		{name: "Из отмененного заказа выхода нет", from: OrderStatusCancelled, to: OrderStatusPending},
+		{name: "Нельзя вернуться назад", from: OrderStatusDelivered, to: OrderStatusShipped},
+		{name: "Неизвестный исходный статус", from: "lost", to: OrderStatusPaid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestOrderStatusRefundTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     OrderStatus
+		to       OrderStatus
+		expected bool
+	}{
+		{name: "Возврат доставленного заказа", from: OrderStatusDelivered, to: OrderStatusRefunded, expected: true},
+		{name: "Частичный возврат доставленного заказа", from: OrderStatusDelivered, to: OrderStatusPartiallyRefunded, expected: true},
+		{name: "Возврат остатка после частичного возврата", from: OrderStatusPartiallyRefunded, to: OrderStatusRefunded, expected: true},
+		{name: "Возврат недоставленного заказа запрещен", from: OrderStatusPaid, to: OrderStatusRefunded},
+		{name: "Возврат не меняет статус на обычный", from: OrderStatusDelivered, to: OrderStatusShipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.from.CanRefundTo(tt.to))
+		})
+	}
+}
+
+func TestOrderStatusIsCancellable(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   OrderStatus
+		expected bool
+	}{
+		{name: "Новый заказ", status: OrderStatusPending, expected: true},
+		{name: "Заказ в сборке", status: OrderStatusProcessing, expected: true},
+		{name: "Оплаченный заказ до сборки", status: OrderStatusPaid},
+		{name: "Отправленный заказ", status: OrderStatusShipped},
+		{name: "Отмененный заказ", status: OrderStatusCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.status.IsCancellable())
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// PaymentStatus определяет состояние платежа
+type PaymentStatus string
+
+const (
+	// PaymentStatusPending - платеж создан, шлюз еще не ответил
+	PaymentStatusPending PaymentStatus = "pending"
+	// PaymentStatusAuthorized - сумма заблокирована, но не списана
+	PaymentStatusAuthorized PaymentStatus = "authorized"
+	// PaymentStatusCaptured - сумма списана
+	PaymentStatusCaptured PaymentStatus = "captured"
+	// PaymentStatusRefunded - списанная сумма возвращена покупателю
+	PaymentStatusRefunded PaymentStatus = "refunded"
+	// PaymentStatusVoided - авторизация отменена без списания
+	PaymentStatusVoided PaymentStatus = "voided"
+	// PaymentStatusFailed - шлюз отклонил платеж или не ответил
+	PaymentStatusFailed PaymentStatus = "failed"
+)
+
+// Payment - попытка оплаты заказа через платежный шлюз
+// На один заказ может приходиться несколько попыток, успешной считается списанная
+type Payment struct {
+	ID              uint          `gorm:"primarykey" json:"id"`
+	OrderID         uint          `gorm:"not null;index" json:"order_id"`
+	Order           *Order        `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE;" json:"-"`
+	Provider        string        `gorm:"type:varchar(32);not null" json:"provider"`
+	Status          PaymentStatus `gorm:"type:varchar(20);not null;default:pending" json:"status"`
+	Amount          Money         `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	AuthorizationID string        `gorm:"type:varchar(64)" json:"authorization_id,omitempty"`
+	TransactionID   string        `gorm:"type:varchar(64);index" json:"transaction_id,omitempty"`
+	FailureReason   string        `json:"failure_reason,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,43 @@
+package domain
+
+// CartLine - рассчитанная позиция корзины
+// UnitPrice - текущая цена товара, по ней считаются суммы; SavedPrice - цена, запомненная
+// при добавлении в корзину. PriceChanged означает, что к оформлению цену нужно подтвердить
+// Discount - доля скидки на товары, приходящаяся на позицию
+type CartLine struct {
+	ItemID       uint   `json:"item_id"`
+	ProductID    uint   `json:"product_id"`
+	Name         string `json:"name"`
+	Quantity     int    `json:"quantity"`
+	UnitPrice    Money  `json:"unit_price"`
+	SavedPrice   Money  `json:"saved_price"`
+	PriceChanged bool   `json:"price_changed"`
+	Subtotal     Money  `json:"subtotal"`
+	Discount     Money  `json:"discount"`
+	TaxRate      int    `json:"tax_rate"`
+	Tax          Money  `json:"tax"`
+}
+
+// AppliedDiscount - скидка, примененная при расчете корзины
+type AppliedDiscount struct {
+	PromotionID uint   `json:"promotion_id"`
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Amount      Money  `json:"amount"`
+}
+
+// CartSummary - итоговый расчет корзины
+// Один и тот же расчет используется для отображения корзины и при оформлении заказа,
+// поэтому сумма в корзине всегда совпадает с суммой заказа
+// Если TaxInclusive, налог уже включен в цены и не добавляется к итогу
+type CartSummary struct {
+	Lines        []CartLine        `json:"lines"`
+	ItemCount    int               `json:"item_count"`
+	Subtotal     Money             `json:"subtotal"`
+	Discounts    []AppliedDiscount `json:"discounts"`
+	Discount     Money             `json:"discount"`
+	Tax          Money             `json:"tax"`
+	TaxInclusive bool              `json:"tax_inclusive"`
+	Shipping     Money             `json:"shipping"`
+	Total        Money             `json:"total"`
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PromotionType определяет способ расчета скидки
+type PromotionType string
+
+const (
+	// PromotionPercentage - скидка в процентах от суммы товаров
+	PromotionPercentage PromotionType = "percentage"
+	// PromotionFixedAmount - фиксированная скидка на корзину
+	PromotionFixedAmount PromotionType = "fixed_amount"
+	// PromotionBuyXGetY - при покупке BuyQuantity единиц товара еще GetQuantity единиц бесплатно
+	PromotionBuyXGetY PromotionType = "buy_x_get_y"
+	// PromotionFreeShipping - бесплатная доставка
+	PromotionFreeShipping PromotionType = "free_shipping"
+)
+
+// Promotion представляет акцию, применяемую по коду купона
+type Promotion struct {
+	ID                uint           `gorm:"primarykey" json:"id"`
+	Code              string         `gorm:"type:varchar(64);uniqueIndex;not null" json:"code"`
+	Description       string         `json:"description"`
+	Type              PromotionType  `gorm:"type:varchar(20);not null" json:"type"`
+	PercentOff        int            `gorm:"not null;default:0" json:"percent_off,omitempty"`
+	AmountOff         Money          `gorm:"embedded;embeddedPrefix:amount_off_" json:"amount_off"`
+	BuyProductID      *uint          `json:"buy_product_id,omitempty"`
+	BuyQuantity       int            `gorm:"not null;default:0" json:"buy_quantity,omitempty"`
+	GetQuantity       int            `gorm:"not null;default:0" json:"get_quantity,omitempty"`
+	MinSubtotal       Money          `gorm:"embedded;embeddedPrefix:min_subtotal_" json:"min_subtotal"`
+	StartsAt          *time.Time     `json:"starts_at,omitempty"`
+	EndsAt            *time.Time     `json:"ends_at,omitempty"`
+	UsageLimit        int            `gorm:"not null;default:0" json:"usage_limit"`
+	UsageLimitPerUser int            `gorm:"not null;default:0" json:"usage_limit_per_user"`
+	UsedCount         int            `gorm:"not null;default:0" json:"used_count"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// PromotionRedemption - факт использования акции в заказе
+// Используется для учета лимита использований на пользователя
+type PromotionRedemption struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	PromotionID uint      `gorm:"not null;index" json:"promotion_id"`
+	UserID      uint      `gorm:"not null;index" json:"user_id"`
+	OrderID     uint      `gorm:"not null;index" json:"order_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// OrderDiscount - скидка, примененная к заказу
+// Сохраняется вместе с заказом, чтобы итог заказа можно было воспроизвести
+// после изменения или удаления акции
+type OrderDiscount struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	OrderID     uint      `gorm:"not null;index" json:"order_id"`
+	PromotionID *uint     `json:"promotion_id,omitempty"`
+	Code        string    `json:"code"`
+	Description string    `json:"description"`
+	Amount      Money     `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Validate проверяет, что параметры акции соответствуют ее типу
+func (p *Promotion) Validate() error {
+	if p.Code == "" {
+		return NewValidationError("promotion code is required")
+	}
+
+	switch p.Type {
+	case PromotionPercentage:
+		if p.PercentOff <= 0 || p.PercentOff > 100 {
+			return NewValidationError("percent_off must be between 1 and 100")
+		}
+	case PromotionFixedAmount:
+		if p.AmountOff.Amount <= 0 {
+			return NewValidationError("amount_off must be positive")
+		}
+	case PromotionBuyXGetY:
+		if p.BuyProductID == nil || p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return NewValidationError("buy_product_id, buy_quantity and get_quantity are required")
+		}
+	case PromotionFreeShipping:
+	default:
+		return NewValidationError(fmt.Sprintf("unknown promotion type %q", p.Type))
+	}
+
+	if p.MinSubtotal.IsNegative() {
+		return NewValidationError("min_subtotal must not be negative")
+	}
+	if p.UsageLimit < 0 || p.UsageLimitPerUser < 0 {
+		return NewValidationError("usage limits must not be negative")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return NewValidationError("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// IsActiveAt сообщает, действует ли акция в момент t
+func (p *Promotion) IsActiveAt(t time.Time) bool {
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !t.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Qualifies сообщает, достаточна ли сумма товаров для применения акции
+func (p *Promotion) Qualifies(subtotal Money) bool {
+	if p.MinSubtotal.IsZero() {
+		return true
+	}
+	return p.MinSubtotal.Currency == subtotal.Currency && subtotal.Amount >= p.MinSubtotal.Amount
+}
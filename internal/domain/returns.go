@@ -0,0 +1,74 @@
+package domain
+
+import "time"
+
+// ReturnStatus определяет состояние заявки на возврат
+type ReturnStatus string
+
+const (
+	// ReturnStatusRequested - заявка создана покупателем и ждет решения
+	ReturnStatusRequested ReturnStatus = "requested"
+	// ReturnStatusApproved - возврат одобрен, деньги возвращаются покупателю
+	ReturnStatusApproved ReturnStatus = "approved"
+	// ReturnStatusRejected - в возврате отказано
+	ReturnStatusRejected ReturnStatus = "rejected"
+)
+
+// OrderReturn - заявка покупателя на возврат части товаров заказа
+type OrderReturn struct {
+	ID         uint         `gorm:"primarykey" json:"id"`
+	OrderID    uint         `gorm:"not null;index" json:"order_id"`
+	Order      *Order       `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE;" json:"-"`
+	UserID     uint         `gorm:"not null;index" json:"user_id"`
+	Status     ReturnStatus `gorm:"type:varchar(20);not null;default:requested;index" json:"status"`
+	Reason     string       `json:"reason"`
+	Items      []ReturnItem `gorm:"foreignKey:ReturnID;constraint:OnDelete:CASCADE;" json:"items"`
+	Amount     Money        `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	ReviewedBy *uint        `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time   `json:"reviewed_at,omitempty"`
+	ReviewNote string       `json:"review_note,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+// ReturnItem - позиция заказа и количество единиц в заявке на возврат
+type ReturnItem struct {
+	ID          uint  `gorm:"primarykey" json:"id"`
+	ReturnID    uint  `gorm:"not null;index" json:"return_id"`
+	OrderItemID uint  `gorm:"not null;index" json:"order_item_id"`
+	Quantity    int   `gorm:"not null;check:quantity > 0" json:"quantity"`
+	Amount      Money `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+}
+
+// RefundStatus определяет состояние возврата денег
+type RefundStatus string
+
+const (
+	// RefundStatusPending - возврат сохранен, шлюз еще не вернул деньги
+	RefundStatusPending RefundStatus = "pending"
+	// RefundStatusSucceeded - деньги возвращены
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	// RefundStatusFailed - шлюз отклонил возврат, деньги нужно вернуть вручную
+	RefundStatusFailed RefundStatus = "failed"
+)
+
+// Refund - возврат денег по заказу
+// PaymentID заполняется, если деньги возвращаются через платежный шлюз, TransactionID - когда шлюз их вернул
+type Refund struct {
+	ID            uint         `gorm:"primarykey" json:"id"`
+	OrderID       uint         `gorm:"not null;index" json:"order_id"`
+	ReturnID      *uint        `gorm:"index" json:"return_id,omitempty"`
+	PaymentID     *uint        `json:"payment_id,omitempty"`
+	Status        RefundStatus `gorm:"type:varchar(20);not null;default:succeeded" json:"status"`
+	Amount        Money        `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	TransactionID string       `gorm:"type:varchar(64)" json:"transaction_id,omitempty"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+	CreatedBy     *uint        `json:"created_by,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// IsOpen проверяет, что по заявке еще не принято решение
+func (r *OrderReturn) IsOpen() bool {
+	return r.Status == ReturnStatusRequested
+}
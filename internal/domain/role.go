@@ -0,0 +1,62 @@
+package domain
+
+// Role определяет роль пользователя в системе
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleStaff    Role = "staff"
+	RoleAdmin    Role = "admin"
+)
+
+// Permission определяет действие, доступ к которому ограничен ролью
+type Permission string
+
+const (
+	// PermissionManageCatalog - создание, изменение и удаление товаров
+	PermissionManageCatalog Permission = "catalog:manage"
+	// PermissionManageOrders - изменение статуса любых заказов
+	PermissionManageOrders Permission = "orders:manage"
+	// PermissionReadAllOrders - просмотр заказов других пользователей
+	PermissionReadAllOrders Permission = "orders:read_all"
+	// PermissionManageUsers - назначение ролей пользователям
+	PermissionManageUsers Permission = "users:manage"
+	// PermissionViewReports - просмотр отчетов магазина
+	PermissionViewReports Permission = "reports:read"
+)
+
+// rolePermissions - матрица прав по ролям
+// Покупатель работает только со своей корзиной и заказами, поэтому
+// дополнительных прав у него нет
+var rolePermissions = map[Role][]Permission{
+	RoleCustomer: {},
+	RoleStaff: {
+		PermissionManageCatalog,
+		PermissionManageOrders,
+		PermissionReadAllOrders,
+		PermissionViewReports,
+	},
+	RoleAdmin: {
+		PermissionManageCatalog,
+		PermissionManageOrders,
+		PermissionReadAllOrders,
+		PermissionManageUsers,
+		PermissionViewReports,
+	},
+}
+
+// IsValid проверяет, что роль известна системе
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can проверяет, есть ли у роли указанное право
+func (r Role) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
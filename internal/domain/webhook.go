@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// WebhookEvent - обработанное событие входящего вебхука
+// Уникальный EventID защищает от повторной обработки одного и того же события
+type WebhookEvent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	EventID   string    `gorm:"type:varchar(128);uniqueIndex;not null" json:"event_id"`
+	Type      string    `gorm:"type:varchar(64);not null" json:"type"`
+	OrderID   uint      `gorm:"not null;index" json:"order_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
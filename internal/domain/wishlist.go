@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// wishlistSlugBytes - длина адреса опубликованного списка в байтах до кодирования в hex
+const wishlistSlugBytes = 12
+
+// Wishlist - список товаров, отложенных пользователем на потом
+// У пользователя один список. Пока Slug не пуст, список можно посмотреть без входа по его адресу
+type Wishlist struct {
+	ID        uint        `gorm:"primarykey" json:"id"`
+	UserID    uint        `gorm:"not null;uniqueIndex" json:"-"`
+	User      *User       `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	Slug      *string     `gorm:"type:varchar(32);uniqueIndex" json:"slug,omitempty"`
+	Items     []SavedItem `gorm:"foreignKey:WishlistID;constraint:OnDelete:CASCADE;" json:"items"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// SavedItem - товар в списке отложенных
+// Каждый товар занимает в списке не больше одной позиции
+type SavedItem struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	WishlistID uint      `gorm:"not null;uniqueIndex:idx_saved_items_product" json:"wishlist_id"`
+	ProductID  uint      `gorm:"not null;uniqueIndex:idx_saved_items_product" json:"product_id"`
+	Product    Product   `gorm:"foreignKey:ProductID" json:"product"`
+	Quantity   int       `gorm:"not null;default:1;check:quantity > 0" json:"quantity"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// IsShared проверяет, что список опубликован
+func (w *Wishlist) IsShared() bool {
+	return w.Slug != nil
+}
+
+// NewWishlistSlug создает случайный адрес для публикации списка
+func NewWishlistSlug() (string, error) {
+	slug := make([]byte, wishlistSlugBytes)
+	if _, err := rand.Read(slug); err != nil {
+		return "", fmt.Errorf("generate wishlist slug: %w", err)
+	}
+	return hex.EncodeToString(slug), nil
+}
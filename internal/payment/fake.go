@@ -0,0 +1,226 @@
+package payment
+
+import (
+	"fmt"
+	"shopping-cart/internal/domain"
+	"strings"
+	"sync"
+)
+
+// Operation - операция платежного шлюза
+type Operation string
+
+const (
+	OperationAuthorize Operation = "authorize"
+	OperationCapture   Operation = "capture"
+	OperationRefund    Operation = "refund"
+	OperationVoid      Operation = "void"
+)
+
+// FailureMode - режим отказа фейкового шлюза
+type FailureMode string
+
+const (
+	// FailureNone - операция выполняется успешно
+	FailureNone FailureMode = ""
+	// FailureDecline - операция отклоняется (ErrDeclined)
+	FailureDecline FailureMode = "decline"
+	// FailureTimeout - шлюз не отвечает (ErrTimeout), состояние не меняется
+	FailureTimeout FailureMode = "timeout"
+)
+
+// Тестовые токены способа оплаты, которые фейковый шлюз отклоняет при авторизации
+const (
+	SourceDecline = "tok_decline"
+	SourceTimeout = "tok_timeout"
+)
+
+// fakeName - имя провайдера фейкового шлюза
+const fakeName = "fake"
+
+// fakeAuthorization - состояние авторизации в фейковом шлюзе
+type fakeAuthorization struct {
+	amount   domain.Money
+	captured bool
+	voided   bool
+}
+
+// fakeTransaction - состояние списания в фейковом шлюзе
+type fakeTransaction struct {
+	amount   domain.Money
+	refunded int64
+}
+
+// FakeGateway - платежный шлюз в памяти процесса для разработки и тестов без сети
+// Отказы задаются режимами по операциям или тестовыми токенами SourceDecline и SourceTimeout
+type FakeGateway struct {
+	mu             sync.Mutex
+	failures       map[Operation]FailureMode
+	authorizations map[string]*fakeAuthorization
+	transactions   map[string]*fakeTransaction
+	seq            int
+}
+
+// NewFakeGateway создает фейковый шлюз с заданными режимами отказа
+func NewFakeGateway(failures map[Operation]FailureMode) *FakeGateway {
+	gateway := &FakeGateway{
+		failures:       make(map[Operation]FailureMode),
+		authorizations: make(map[string]*fakeAuthorization),
+		transactions:   make(map[string]*fakeTransaction),
+	}
+	for operation, mode := range failures {
+		gateway.failures[operation] = mode
+	}
+	return gateway
+}
+
+// ParseFailures разбирает режимы отказа из строки вида "authorize=decline,capture=timeout"
+func ParseFailures(value string) (map[Operation]FailureMode, error) {
+	failures := make(map[Operation]FailureMode)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, mode, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid payment failure %q: expected operation=mode", pair)
+		}
+		operation := Operation(strings.TrimSpace(name))
+		switch operation {
+		case OperationAuthorize, OperationCapture, OperationRefund, OperationVoid:
+		default:
+			return nil, fmt.Errorf("unknown payment operation %q", name)
+		}
+		failure := FailureMode(strings.TrimSpace(mode))
+		switch failure {
+		case FailureNone, FailureDecline, FailureTimeout:
+		default:
+			return nil, fmt.Errorf("unknown payment failure mode %q", mode)
+		}
+		failures[operation] = failure
+	}
+	return failures, nil
+}
+
+// SetFailure задает режим отказа операции; FailureNone отключает отказ
+func (g *FakeGateway) SetFailure(operation Operation, mode FailureMode) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failures[operation] = mode
+}
+
+// Name возвращает имя провайдера
+func (g *FakeGateway) Name() string {
+	return fakeName
+}
+
+// Authorize блокирует сумму на фейковом счете
+func (g *FakeGateway) Authorize(req AuthorizeRequest) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch req.Source {
+	case SourceDecline:
+		return "", fmt.Errorf("%w: card declined", ErrDeclined)
+	case SourceTimeout:
+		return "", ErrTimeout
+	}
+	if err := g.fail(OperationAuthorize); err != nil {
+		return "", err
+	}
+	if req.Source == "" {
+		return "", fmt.Errorf("%w: payment source is required", ErrDeclined)
+	}
+	if req.Amount.IsNegative() {
+		return "", fmt.Errorf("%w: amount must not be negative", ErrDeclined)
+	}
+
+	id := g.nextID("auth")
+	g.authorizations[id] = &fakeAuthorization{amount: req.Amount}
+	return id, nil
+}
+
+// Capture списывает авторизованную сумму
+// Сумма не может превышать авторизованную, повторное списание запрещено
+func (g *FakeGateway) Capture(authorizationID string, amount domain.Money) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.fail(OperationCapture); err != nil {
+		return "", err
+	}
+	authorization, ok := g.authorizations[authorizationID]
+	if !ok {
+		return "", fmt.Errorf("unknown authorization %q", authorizationID)
+	}
+	if authorization.captured || authorization.voided {
+		return "", fmt.Errorf("authorization %q is already closed", authorizationID)
+	}
+	if amount.Currency != authorization.amount.Currency || amount.Amount > authorization.amount.Amount {
+		return "", fmt.Errorf("capture of %s exceeds authorized %s", amount, authorization.amount)
+	}
+
+	authorization.captured = true
+	id := g.nextID("txn")
+	g.transactions[id] = &fakeTransaction{amount: amount}
+	return id, nil
+}
+
+// Refund возвращает часть списанной суммы
+// Сумма всех возвратов не может превышать списанную
+func (g *FakeGateway) Refund(transactionID string, amount domain.Money) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.fail(OperationRefund); err != nil {
+		return "", err
+	}
+	transaction, ok := g.transactions[transactionID]
+	if !ok {
+		return "", fmt.Errorf("unknown transaction %q", transactionID)
+	}
+	if amount.Currency != transaction.amount.Currency || amount.Amount <= 0 ||
+		transaction.refunded+amount.Amount > transaction.amount.Amount {
+		return "", fmt.Errorf("%w: refund of %s exceeds captured %s", ErrDeclined, amount, transaction.amount)
+	}
+
+	transaction.refunded += amount.Amount
+	return g.nextID("refund"), nil
+}
+
+// Void отменяет авторизацию без списания
+func (g *FakeGateway) Void(authorizationID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.fail(OperationVoid); err != nil {
+		return err
+	}
+	authorization, ok := g.authorizations[authorizationID]
+	if !ok {
+		return fmt.Errorf("unknown authorization %q", authorizationID)
+	}
+	if authorization.captured {
+		return fmt.Errorf("authorization %q is already captured", authorizationID)
+	}
+	authorization.voided = true
+	return nil
+}
+
+// fail возвращает ошибку, если для операции задан режим отказа
+func (g *FakeGateway) fail(operation Operation) error {
+	switch g.failures[operation] {
+	case FailureDecline:
+		return fmt.Errorf("%w: %s rejected", ErrDeclined, operation)
+	case FailureTimeout:
+		return ErrTimeout
+	}
+	return nil
+}
+
+// nextID выдает идентификатор объекта шлюза
+func (g *FakeGateway) nextID(prefix string) string {
+	g.seq++
+	return fmt.Sprintf("%s_%s_%d", fakeName, prefix, g.seq)
+}
@@ -0,0 +1,88 @@
+package payment
+
+import (
+	"shopping-cart/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeGatewayAuthorize(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      map[Operation]FailureMode
+		source        string
+		expectedError error
+	}{
+		{name: "Успешная авторизация", source: "tok_visa"},
+		{name: "Тестовый токен отказа", source: SourceDecline, expectedError: ErrDeclined},
+		{name: "Тестовый токен таймаута", source: SourceTimeout, expectedError: ErrTimeout},
+		{name: "Режим отказа", failures: map[Operation]FailureMode{OperationAuthorize: FailureDecline}, source: "tok_visa", expectedError: ErrDeclined},
+		{name: "Режим таймаута", failures: map[Operation]FailureMode{OperationAuthorize: FailureTimeout}, source: "tok_visa", expectedError: ErrTimeout},
+		{name: "Без источника оплаты", source: "", expectedError: ErrDeclined},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := NewFakeGateway(tt.failures)
+			id, err := gateway.Authorize(AuthorizeRequest{OrderID: 1, Amount: domain.NewMoney(10000, "RUB"), Source: tt.source})
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Empty(t, id)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, id)
+			}
+		})
+	}
+}
+
+func TestFakeGatewayLifecycle(t *testing.T) {
+	gateway := NewFakeGateway(nil)
+	amount := domain.NewMoney(10000, "RUB")
+
+	authorizationID, err := gateway.Authorize(AuthorizeRequest{OrderID: 1, Amount: amount, Source: "tok_visa"})
+	assert.NoError(t, err)
+
+	_, err = gateway.Capture(authorizationID, domain.NewMoney(20000, "RUB"))
+	assert.Error(t, err, "нельзя списать больше авторизованного")
+
+	transactionID, err := gateway.Capture(authorizationID, amount)
+	assert.NoError(t, err)
+
+	_, err = gateway.Capture(authorizationID, amount)
+	assert.Error(t, err, "повторное списание запрещено")
+	assert.Error(t, gateway.Void(authorizationID), "списанную авторизацию нельзя отменить")
+
+	_, err = gateway.Refund(transactionID, domain.NewMoney(4000, "RUB"))
+	assert.NoError(t, err)
+	_, err = gateway.Refund(transactionID, domain.NewMoney(7000, "RUB"))
+	assert.ErrorIs(t, err, ErrDeclined, "возвраты не могут превышать списанную сумму")
+	_, err = gateway.Refund(transactionID, domain.NewMoney(6000, "RUB"))
+	assert.NoError(t, err)
+
+	gateway.SetFailure(OperationVoid, FailureTimeout)
+	otherID, err := gateway.Authorize(AuthorizeRequest{OrderID: 2, Amount: amount, Source: "tok_visa"})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, gateway.Void(otherID), ErrTimeout)
+
+	gateway.SetFailure(OperationVoid, FailureNone)
+	assert.NoError(t, gateway.Void(otherID))
+	_, err = gateway.Capture(otherID, amount)
+	assert.Error(t, err, "отмененную авторизацию нельзя списать")
+}
+
+func TestParseFailures(t *testing.T) {
+	failures, err := ParseFailures("authorize=decline, capture=timeout")
+	assert.NoError(t, err)
+	assert.Equal(t, map[Operation]FailureMode{OperationAuthorize: FailureDecline, OperationCapture: FailureTimeout}, failures)
+
+	failures, err = ParseFailures("")
+	assert.NoError(t, err)
+	assert.Empty(t, failures)
+
+	for _, value := range []string{"authorize", "charge=decline", "capture=explode"} {
+		_, err := ParseFailures(value)
+		assert.Error(t, err, value)
+	}
+}
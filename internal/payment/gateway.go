@@ -0,0 +1,38 @@
+package payment
+
+import (
+	"errors"
+	"shopping-cart/internal/domain"
+)
+
+var (
+	// ErrDeclined возвращается, если платеж отклонен эмитентом или шлюзом
+	ErrDeclined = errors.New("payment declined")
+	// ErrTimeout возвращается, если шлюз не ответил вовремя
+	// Результат операции при этом неизвестен
+	ErrTimeout = errors.New("payment gateway timeout")
+)
+
+// AuthorizeRequest - параметры авторизации платежа
+type AuthorizeRequest struct {
+	OrderID uint
+	Amount  domain.Money
+	// Source - токен способа оплаты, полученный клиентом от платежного провайдера
+	Source string
+}
+
+// Gateway - платежный шлюз (PaymentGateway)
+// Оплата проходит в два шага: авторизация блокирует сумму, списание (capture) ее забирает.
+// Авторизацию, которая не была списана, нужно отменить (void), списанную сумму - вернуть (refund)
+type Gateway interface {
+	// Name возвращает имя провайдера, которое сохраняется в платеже
+	Name() string
+	// Authorize блокирует сумму и возвращает идентификатор авторизации
+	Authorize(req AuthorizeRequest) (string, error)
+	// Capture списывает авторизованную сумму и возвращает идентификатор транзакции
+	Capture(authorizationID string, amount domain.Money) (string, error)
+	// Refund возвращает часть или всю списанную сумму и возвращает идентификатор возврата
+	Refund(transactionID string, amount domain.Money) (string, error)
+	// Void отменяет авторизацию, по которой еще не было списания
+	Void(authorizationID string) error
+}
@@ -0,0 +1,176 @@
+package pricing
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/shipping"
+	"shopping-cart/internal/tax"
+)
+
+// Line - позиция для расчета: товар с актуальной ценой и количество
+// SavedPrice - цена позиции на момент добавления в корзину; если не задана,
+// позиция считается по текущей цене без изменений
+type Line struct {
+	ItemID     uint
+	Product    *domain.Product
+	Quantity   int
+	SavedPrice domain.Money
+}
+
+// Request - входные данные для расчета корзины
+type Request struct {
+	Lines []Line
+	// Promotion - акция по купону корзины; применимость купона для пользователя
+	// (срок действия, лимиты использований) проверяется до расчета
+	Promotion *domain.Promotion
+	// Region - код страны доставки: определяет налоговый регион и зону доставки
+	// Пустая строка означает налоговый регион по умолчанию
+	Region string
+	// ShippingMethod - способ доставки; если не указан, доставка не рассчитывается
+	ShippingMethod string
+}
+
+// Calculator рассчитывает итоги корзины
+type Calculator interface {
+	Calculate(req Request) (*domain.CartSummary, error)
+}
+
+// Engine - расчет стоимости корзины, общий для корзины и оформления заказа
+type Engine struct {
+	taxes    *tax.Table
+	shipping *shipping.Table
+}
+
+// NewEngine создает новый экземпляр Engine
+func NewEngine(taxes *tax.Table, rates *shipping.Table) *Engine {
+	return &Engine{taxes: taxes, shipping: rates}
+}
+
+// LinesFromCart строит позиции для расчета из элементов корзины
+// Товары должны быть загружены вместе с элементами
+func LinesFromCart(items []domain.CartItem) []Line {
+	lines := make([]Line, 0, len(items))
+	for i := range items {
+		lines = append(lines, Line{
+			ItemID:     items[i].ID,
+			Product:    &items[i].Product,
+			Quantity:   items[i].Quantity,
+			SavedPrice: items[i].Price,
+		})
+	}
+	return lines
+}
+
+// Calculate рассчитывает стоимость позиций и итог корзины
+// Все товары должны быть в одной валюте
+func (e *Engine) Calculate(req Request) (*domain.CartSummary, error) {
+	currency := domain.DefaultCurrency
+	if len(req.Lines) > 0 {
+		currency = req.Lines[0].Product.Price.Currency
+	}
+
+	summary := &domain.CartSummary{
+		Lines:     make([]domain.CartLine, 0, len(req.Lines)),
+		Subtotal:  domain.Zero(currency),
+		Discounts: []domain.AppliedDiscount{},
+		Discount:  domain.Zero(currency),
+		Tax:       domain.Zero(currency),
+		Shipping:  domain.Zero(currency),
+	}
+
+	for _, line := range req.Lines {
+		lineSubtotal := line.Product.Price.Mul(line.Quantity)
+		subtotal, err := summary.Subtotal.Add(lineSubtotal)
+		if err != nil {
+			return nil, err
+		}
+		summary.Subtotal = subtotal
+		summary.ItemCount += line.Quantity
+		savedPrice := line.SavedPrice
+		if savedPrice == (domain.Money{}) {
+			savedPrice = line.Product.Price
+		}
+		summary.Lines = append(summary.Lines, domain.CartLine{
+			ItemID:       line.ItemID,
+			ProductID:    line.Product.ID,
+			Name:         line.Product.Name,
+			Quantity:     line.Quantity,
+			UnitPrice:    line.Product.Price,
+			SavedPrice:   savedPrice,
+			PriceChanged: savedPrice != line.Product.Price,
+			Subtotal:     lineSubtotal,
+		})
+	}
+
+	if req.ShippingMethod != "" {
+		weight := 0
+		for _, line := range req.Lines {
+			weight += line.Product.WeightGrams * line.Quantity
+		}
+		cost, err := e.shipping.Quote(req.ShippingMethod, req.Region, weight)
+		if err != nil {
+			return nil, err
+		}
+		summary.Shipping = cost
+	}
+
+	var goodsDiscount int64
+	if req.Promotion != nil && req.Promotion.Qualifies(summary.Subtotal) {
+		var err error
+		if goodsDiscount, err = applyPromotion(req.Promotion, summary); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.applyTax(req.Region, summary, req.Lines, goodsDiscount); err != nil {
+		return nil, err
+	}
+
+	total, err := summary.Subtotal.Sub(summary.Discount)
+	if err != nil {
+		return nil, err
+	}
+	if !summary.TaxInclusive {
+		if total, err = total.Add(summary.Tax); err != nil {
+			return nil, err
+		}
+	}
+	if total, err = total.Add(summary.Shipping); err != nil {
+		return nil, err
+	}
+	summary.Total = total
+	return summary, nil
+}
+
+// applyTax рассчитывает налог по каждой позиции и по корзине в целом
+// Скидка на товары распределяется по позициям пропорционально их сумме,
+// налог начисляется на сумму позиции после скидки. Доставка налогом не облагается
+func (e *Engine) applyTax(region string, summary *domain.CartSummary, lines []Line, goodsDiscount int64) error {
+	summary.TaxInclusive = e.taxes.Inclusive()
+
+	remaining := goodsDiscount
+	for i := range summary.Lines {
+		line := &summary.Lines[i]
+
+		share := remaining
+		if i < len(summary.Lines)-1 && summary.Subtotal.Amount > 0 {
+			share = goodsDiscount * line.Subtotal.Amount / summary.Subtotal.Amount
+		}
+		remaining -= share
+
+		rate, err := e.taxes.Rate(region, lines[i].Product.TaxCategory)
+		if err != nil {
+			return err
+		}
+		line.Discount = domain.NewMoney(share, line.Subtotal.Currency)
+		taxable := domain.NewMoney(line.Subtotal.Amount-share, line.Subtotal.Currency)
+		line.TaxRate = rate
+		line.Tax = e.taxes.Tax(taxable, rate)
+
+		total, err := summary.Tax.Add(line.Tax)
+		if err != nil {
+			return err
+		}
+		summary.Tax = total
+	}
+	return nil
+}
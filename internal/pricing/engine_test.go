@@ -0,0 +1,254 @@
+package pricing
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/shipping"
+	"shopping-cart/internal/tax"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// zeroTaxes - налоговая таблица с нулевыми ставками для тестов без налога
+var zeroTaxes = &tax.Table{
+	Mode:          tax.ModeExclusive,
+	DefaultRegion: "RU",
+	Regions:       map[string]tax.Rates{"RU": {tax.DefaultCategory: 0}},
+}
+
+// newTestRates создает тарифы доставки по России: до 1 кг - 300, до 5 кг - 500
+func newTestRates(t *testing.T) *shipping.Table {
+	rates, err := shipping.NewTable("RUB", map[string][]string{"domestic": {"RU"}}, map[string]shipping.Method{
+		"standard": {Rates: map[string][]shipping.Rate{"domestic": {
+			{MaxWeightGrams: 1000, Price: "300"},
+			{MaxWeightGrams: 5000, Price: "500"},
+		}}},
+	})
+	assert.NoError(t, err)
+	return rates
+}
+
+func TestEngineCalculate(t *testing.T) {
+	engine := NewEngine(zeroTaxes, newTestRates(t))
+
+	tests := []struct {
+		name          string
+		lines         []Line
+		expectedCount int
+		expectedTotal domain.Money
+		expectedError error
+	}{
+		{
+			name:          "Пустая корзина",
+			expectedTotal: domain.Zero(domain.DefaultCurrency),
+		},
+		{
+			name: "Несколько позиций",
+			lines: []Line{
+				{ItemID: 1, Product: &domain.Product{ID: 10, Price: domain.NewMoney(1999, "RUB")}, Quantity: 2},
+				{ItemID: 2, Product: &domain.Product{ID: 11, Price: domain.NewMoney(500, "RUB")}, Quantity: 1},
+			},
+			expectedCount: 3,
+			expectedTotal: domain.NewMoney(4498, "RUB"),
+		},
+		{
+			name: "Разные валюты",
+			lines: []Line{
+				{ItemID: 1, Product: &domain.Product{ID: 10, Price: domain.NewMoney(100, "RUB")}, Quantity: 1},
+				{ItemID: 2, Product: &domain.Product{ID: 11, Price: domain.NewMoney(100, "USD")}, Quantity: 1},
+			},
+			expectedError: domain.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := engine.Calculate(Request{Lines: tt.lines})
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCount, summary.ItemCount)
+			assert.Equal(t, tt.expectedTotal, summary.Total)
+			assert.Len(t, summary.Lines, len(tt.lines))
+			for i, line := range summary.Lines {
+				assert.Equal(t, tt.lines[i].Product.Price.Mul(tt.lines[i].Quantity), line.Subtotal)
+			}
+		})
+	}
+}
+
+func TestEnginePromotions(t *testing.T) {
+	engine := NewEngine(zeroTaxes, newTestRates(t))
+	productID := uint(10)
+	lines := []Line{
+		{ItemID: 1, Product: &domain.Product{ID: 10, Price: domain.NewMoney(1000, "RUB")}, Quantity: 5},
+		{ItemID: 2, Product: &domain.Product{ID: 11, Price: domain.NewMoney(333, "RUB")}, Quantity: 1},
+	}
+
+	tests := []struct {
+		name             string
+		promotion        *domain.Promotion
+		expectedDiscount domain.Money
+	}{
+		{
+			name:             "Процент округляется вниз",
+			promotion:        &domain.Promotion{Type: domain.PromotionPercentage, PercentOff: 15},
+			expectedDiscount: domain.NewMoney(799, "RUB"),
+		},
+		{
+			name:             "Фиксированная скидка",
+			promotion:        &domain.Promotion{Type: domain.PromotionFixedAmount, AmountOff: domain.NewMoney(500, "RUB")},
+			expectedDiscount: domain.NewMoney(500, "RUB"),
+		},
+		{
+			name:             "Фиксированная скидка не больше суммы корзины",
+			promotion:        &domain.Promotion{Type: domain.PromotionFixedAmount, AmountOff: domain.NewMoney(100000, "RUB")},
+			expectedDiscount: domain.NewMoney(5333, "RUB"),
+		},
+		{
+			name:             "Два по цене одного",
+			promotion:        &domain.Promotion{Type: domain.PromotionBuyXGetY, BuyProductID: &productID, BuyQuantity: 1, GetQuantity: 1},
+			expectedDiscount: domain.NewMoney(2000, "RUB"),
+		},
+		{
+			name:             "Минимальная сумма не достигнута",
+			promotion:        &domain.Promotion{Type: domain.PromotionPercentage, PercentOff: 10, MinSubtotal: domain.NewMoney(10000, "RUB")},
+			expectedDiscount: domain.Zero("RUB"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := engine.Calculate(Request{Lines: lines, Promotion: tt.promotion})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedDiscount, summary.Discount)
+
+			expectedTotal, _ := summary.Subtotal.Sub(tt.expectedDiscount)
+			assert.Equal(t, expectedTotal, summary.Total)
+		})
+	}
+}
+
+func TestEngineTax(t *testing.T) {
+	regions := map[string]tax.Rates{
+		"RU": {tax.DefaultCategory: 2000, "reduced": 1000},
+	}
+	lines := []Line{
+		{ItemID: 1, Product: &domain.Product{ID: 10, Price: domain.NewMoney(3000, "RUB")}, Quantity: 1},
+		{ItemID: 2, Product: &domain.Product{ID: 11, Price: domain.NewMoney(1000, "RUB"), TaxCategory: "reduced"}, Quantity: 1},
+	}
+	sale := &domain.Promotion{Type: domain.PromotionFixedAmount, AmountOff: domain.NewMoney(400, "RUB")}
+
+	tests := []struct {
+		name              string
+		mode              tax.Mode
+		promotion         *domain.Promotion
+		expectedTaxes     []domain.Money
+		expectedDiscounts []int64
+		expectedTotal     domain.Money
+	}{
+		{
+			name:              "Налог сверху цены",
+			mode:              tax.ModeExclusive,
+			expectedTaxes:     []domain.Money{domain.NewMoney(600, "RUB"), domain.NewMoney(100, "RUB")},
+			expectedDiscounts: []int64{0, 0},
+			expectedTotal:     domain.NewMoney(4700, "RUB"),
+		},
+		{
+			name:              "Налог включен в цену",
+			mode:              tax.ModeInclusive,
+			expectedTaxes:     []domain.Money{domain.NewMoney(500, "RUB"), domain.NewMoney(91, "RUB")},
+			expectedDiscounts: []int64{0, 0},
+			expectedTotal:     domain.NewMoney(4000, "RUB"),
+		},
+		{
+			name:              "Скидка распределяется по позициям до расчета налога",
+			mode:              tax.ModeExclusive,
+			promotion:         sale,
+			expectedTaxes:     []domain.Money{domain.NewMoney(540, "RUB"), domain.NewMoney(90, "RUB")},
+			expectedDiscounts: []int64{300, 100},
+			expectedTotal:     domain.NewMoney(4230, "RUB"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := tax.NewTable(tt.mode, "RU", regions)
+			assert.NoError(t, err)
+
+			summary, err := NewEngine(table, newTestRates(t)).Calculate(Request{Lines: lines, Promotion: tt.promotion})
+			assert.NoError(t, err)
+			var totalTax int64
+			for i, line := range summary.Lines {
+				assert.Equal(t, tt.expectedTaxes[i], line.Tax)
+				assert.Equal(t, domain.NewMoney(tt.expectedDiscounts[i], "RUB"), line.Discount)
+				totalTax += line.Tax.Amount
+			}
+			assert.Equal(t, totalTax, summary.Tax.Amount)
+			assert.Equal(t, tt.mode == tax.ModeInclusive, summary.TaxInclusive)
+			assert.Equal(t, tt.expectedTotal, summary.Total)
+		})
+	}
+}
+
+func TestEngineShipping(t *testing.T) {
+	taxes, err := tax.NewTable(tax.ModeExclusive, "RU", map[string]tax.Rates{"RU": {tax.DefaultCategory: 2000}})
+	assert.NoError(t, err)
+	engine := NewEngine(taxes, newTestRates(t))
+	lines := []Line{
+		{ItemID: 1, Product: &domain.Product{ID: 10, Price: domain.NewMoney(100000, "RUB"), WeightGrams: 600}, Quantity: 2},
+	}
+
+	tests := []struct {
+		name             string
+		method           string
+		region           string
+		promotion        *domain.Promotion
+		expectedShipping domain.Money
+		expectedTotal    domain.Money
+		expectedError    error
+	}{
+		{
+			name:             "Без способа доставки",
+			expectedShipping: domain.Zero("RUB"),
+			expectedTotal:    domain.NewMoney(240000, "RUB"),
+		},
+		{
+			name:             "Тариф по весу, доставка налогом не облагается",
+			method:           "standard",
+			region:           "RU",
+			expectedShipping: domain.NewMoney(50000, "RUB"),
+			expectedTotal:    domain.NewMoney(290000, "RUB"),
+		},
+		{
+			name:             "Бесплатная доставка по акции",
+			method:           "standard",
+			region:           "RU",
+			promotion:        &domain.Promotion{Code: "FREESHIP", Type: domain.PromotionFreeShipping},
+			expectedShipping: domain.NewMoney(50000, "RUB"),
+			expectedTotal:    domain.NewMoney(240000, "RUB"),
+		},
+		{
+			name:          "Страна вне зон доставки",
+			method:        "standard",
+			region:        "BY",
+			expectedError: domain.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := engine.Calculate(Request{Lines: lines, Promotion: tt.promotion, Region: tt.region, ShippingMethod: tt.method})
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedShipping, summary.Shipping)
+			assert.Equal(t, domain.NewMoney(40000, "RUB"), summary.Tax)
+			assert.Equal(t, tt.expectedTotal, summary.Total)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package pricing
+
+import "shopping-cart/internal/domain"
+
+// applyPromotion рассчитывает скидку по акции и добавляет ее в итог корзины
+// Возвращает часть скидки, приходящуюся на товары: скидка на доставку не уменьшает
+// налогооблагаемую сумму. Скидка на товары не превышает их сумму, на доставку - ее стоимость
+func applyPromotion(promotion *domain.Promotion, summary *domain.CartSummary) (int64, error) {
+	amount := promotionDiscount(promotion, summary)
+
+	onShipping := promotion.Type == domain.PromotionFreeShipping
+	limit := summary.Subtotal
+	if onShipping {
+		limit = summary.Shipping
+	}
+	if amount.Amount > limit.Amount {
+		amount = limit
+	}
+	if amount.Amount <= 0 {
+		return 0, nil
+	}
+
+	discount, err := summary.Discount.Add(amount)
+	if err != nil {
+		return 0, err
+	}
+	summary.Discount = discount
+	summary.Discounts = append(summary.Discounts, domain.AppliedDiscount{
+		PromotionID: promotion.ID,
+		Code:        promotion.Code,
+		Description: promotion.Description,
+		Amount:      amount,
+	})
+	if onShipping {
+		return 0, nil
+	}
+	return amount.Amount, nil
+}
+
+// promotionDiscount возвращает размер скидки по акции в валюте корзины
+func promotionDiscount(promotion *domain.Promotion, summary *domain.CartSummary) domain.Money {
+	currency := summary.Subtotal.Currency
+
+	switch promotion.Type {
+	case domain.PromotionPercentage:
+		// Округляем скидку вниз до минимальной единицы валюты
+		return domain.NewMoney(summary.Subtotal.Amount*int64(promotion.PercentOff)/100, currency)
+	case domain.PromotionFixedAmount:
+		if promotion.AmountOff.Currency != currency {
+			return domain.Zero(currency)
+		}
+		return promotion.AmountOff
+	case domain.PromotionBuyXGetY:
+		discount := domain.Zero(currency)
+		group := promotion.BuyQuantity + promotion.GetQuantity
+		for _, line := range summary.Lines {
+			if promotion.BuyProductID == nil || line.ProductID != *promotion.BuyProductID {
+				continue
+			}
+			free := line.Quantity / group * promotion.GetQuantity
+			discount.Amount += line.UnitPrice.Mul(free).Amount
+		}
+		return discount
+	case domain.PromotionFreeShipping:
+		return summary.Shipping
+	}
+	return domain.Zero(currency)
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type addressRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressRepository(db *gorm.DB) repository.AddressRepository {
+	return &addressRepository{db: db}
+}
+
+func (r *addressRepository) Create(address *domain.Address) error {
+	return r.db.Create(address).Error
+}
+
+func (r *addressRepository) GetByID(id uint) (*domain.Address, error) {
+	var address domain.Address
+	err := r.db.First(&address, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (r *addressRepository) GetByUserID(userID uint) ([]domain.Address, error) {
+	var addresses []domain.Address
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *addressRepository) Update(address *domain.Address) error {
+	return r.db.Save(address).Error
+}
+
+func (r *addressRepository) Delete(id uint) error {
+	result := r.db.Delete(&domain.Address{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type idempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyKeyRepository(db *gorm.DB) repository.IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{db: db}
+}
+
+// Claim вставляет ключ с ON CONFLICT DO NOTHING, чтобы из параллельных
+// запросов с одним ключом выполнялся только один
+func (r *idempotencyKeyRepository) Claim(key *domain.IdempotencyKey) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+		DoNothing: true,
+	}).Create(key)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *idempotencyKeyRepository) GetByKey(userID uint, key string) (*domain.IdempotencyKey, error) {
+	var record domain.IdempotencyKey
+	err := r.db.Where("user_id = ? AND key = ?", userID, key).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *idempotencyKeyRepository) Complete(key *domain.IdempotencyKey) error {
+	result := r.db.Model(&domain.IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", key.UserID, key.Key).
+		Updates(map[string]any{
+			"status_code":  key.StatusCode,
+			"content_type": key.ContentType,
+			"body":         key.Body,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *idempotencyKeyRepository) Release(userID uint, key string) error {
+	return r.db.Where("user_id = ? AND key = ?", userID, key).Delete(&domain.IdempotencyKey{}).Error
+}
@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"fmt"
+	"math"
+	"shopping-cart/internal/domain"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// legacyMoneyColumns - колонки double precision, которые до перехода на domain.Money
+// хранили суммы в рублях. Новые значения лежат в <column>_amount и <column>_currency
+var legacyMoneyColumns = []struct {
+	table  string
+	column string
+}{
+	{table: "products", column: "price"},
+	{table: "order_items", column: "price"},
+	{table: "orders", column: "total"},
+}
+
+// MigrateMoneyColumns переносит суммы из устаревших колонок в минимальные единицы
+// валюты и удаляет старые колонки. Вызывается после AutoMigrate, который создает
+// новые колонки. Повторный запуск ничего не делает
+func MigrateMoneyColumns(db *gorm.DB, currency string) error {
+	scale := int64(math.Pow10(domain.MinorUnits(currency)))
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, legacy := range legacyMoneyColumns {
+			if !tx.Migrator().HasColumn(legacy.table, legacy.column) {
+				continue
+			}
+
+			query := fmt.Sprintf(
+				"UPDATE %[1]s SET %[2]s_amount = ROUND(%[2]s::numeric * ?)::bigint, %[2]s_currency = ? WHERE %[2]s IS NOT NULL",
+				legacy.table, legacy.column,
+			)
+			if err := tx.Exec(query, scale, currency).Error; err != nil {
+				return fmt.Errorf("migrate %s.%s: %w", legacy.table, legacy.column, err)
+			}
+
+			if err := tx.Migrator().DropColumn(legacy.table, legacy.column); err != nil {
+				return fmt.Errorf("drop %s.%s: %w", legacy.table, legacy.column, err)
+			}
+		}
+		return nil
+	})
+}
+
+// MergeDuplicateCarts подготавливает данные к уникальным индексам корзин: переносит позиции
+// из лишних корзин пользователя в самую раннюю и объединяет повторяющиеся позиции одного товара,
+// суммируя количество в пределах domain.MaxLineQuantity. Вызывается до AutoMigrate, который
+// создает индексы. Повторный запуск ничего не делает
+func MergeDuplicateCarts(db *gorm.DB) error {
+	if !db.Migrator().HasTable("carts") || !db.Migrator().HasTable("cart_items") {
+		return nil
+	}
+
+	steps := []struct {
+		query string
+		args  []any
+	}{
+		{query: `UPDATE cart_items SET cart_id = d.keep_id
+			FROM (SELECT id, MIN(id) OVER (PARTITION BY user_id) AS keep_id FROM carts WHERE deleted_at IS NULL AND user_id IS NOT NULL) d
+			WHERE cart_items.cart_id = d.id AND d.id <> d.keep_id`},
+		{query: `UPDATE carts SET deleted_at = NOW()
+			FROM (SELECT id, MIN(id) OVER (PARTITION BY user_id) AS keep_id FROM carts WHERE deleted_at IS NULL AND user_id IS NOT NULL) d
+			WHERE carts.id = d.id AND d.id <> d.keep_id`},
+		{query: `UPDATE cart_items SET quantity = LEAST(d.total, ?)
+			FROM (SELECT MIN(id) AS keep_id, SUM(quantity) AS total FROM cart_items WHERE deleted_at IS NULL
+				GROUP BY cart_id, product_id HAVING COUNT(*) > 1) d
+			WHERE cart_items.id = d.keep_id`, args: []any{domain.MaxLineQuantity}},
+		{query: `UPDATE cart_items SET deleted_at = NOW()
+			FROM (SELECT id, MIN(id) OVER (PARTITION BY cart_id, product_id) AS keep_id FROM cart_items WHERE deleted_at IS NULL) d
+			WHERE cart_items.id = d.id AND d.id <> d.keep_id`},
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i, step := range steps {
+			if err := tx.Exec(step.query, step.args...).Error; err != nil {
+				return fmt.Errorf("merge duplicate carts, step %d: %w", i+1, err)
+			}
+		}
+		return nil
+	})
+}
+
+// legacyUserPasswordHash - хэш пароля пользователей, созданных AdoptOrphanedUsers.
+// Он не является bcrypt-хэшем, поэтому войти под такими пользователями нельзя
+const legacyUserPasswordHash = "!"
+
+// AdoptOrphanedUsers создает пользователей-заглушки для user_id корзин и заказов, которым
+// не соответствует ни один пользователь. До появления пользователей все корзины и заказы
+// сохранялись с user_id = 1, и без заглушек AutoMigrate не может добавить внешние ключи
+// carts.user_id и orders.user_id. Вызывается до AutoMigrate. Повторный запуск ничего не делает
+func AdoptOrphanedUsers(db *gorm.DB) error {
+	var sources []string
+	for _, table := range []string{"carts", "orders"} {
+		if db.Migrator().HasTable(table) {
+			sources = append(sources, fmt.Sprintf("SELECT user_id FROM %s WHERE user_id IS NOT NULL", table))
+		}
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(&domain.User{}); err != nil {
+			return fmt.Errorf("adopt orphaned users: %w", err)
+		}
+
+		query := fmt.Sprintf(`INSERT INTO users (id, email, name, role, password_hash, created_at, updated_at)
+			SELECT DISTINCT o.user_id, 'legacy-user-' || o.user_id || '@legacy.invalid', 'Legacy user', ?, ?, NOW(), NOW()
+			FROM (%s) o WHERE NOT EXISTS (SELECT 1 FROM users WHERE users.id = o.user_id)`, strings.Join(sources, " UNION "))
+		result := tx.Exec(query, domain.RoleCustomer, legacyUserPasswordHash)
+		if result.Error != nil {
+			return fmt.Errorf("adopt orphaned users: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		// Идентификаторы заглушек заданы явно: следующий зарегистрированный пользователь
+		// должен получить id после них
+		if err := tx.Exec("SELECT setval(pg_get_serial_sequence('users', 'id'), (SELECT MAX(id) FROM users))").Error; err != nil {
+			return fmt.Errorf("adopt orphaned users: %w", err)
+		}
+		return nil
+	})
+}
+
+// AllowGuestCarts снимает NOT NULL с carts.user_id, чтобы корзина могла принадлежать гостю.
+// AutoMigrate не ослабляет ограничения существующих колонок. Повторный запуск ничего не делает
+func AllowGuestCarts(db *gorm.DB) error {
+	if !db.Migrator().HasTable("carts") {
+		return nil
+	}
+	if err := db.Exec("ALTER TABLE carts ALTER COLUMN user_id DROP NOT NULL").Error; err != nil {
+		return fmt.Errorf("allow guest carts: %w", err)
+	}
+	return nil
+}
+
+// productIndexes - индексы под сортировки каталога и поиск по подстроке названия.
+// Сортировки дополняются id, поэтому индексы составные; Money встраивается в несколько
+// моделей, и индекс по цене товара нельзя объявить тегом gorm
+var productIndexes = []string{
+	"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+	"CREATE INDEX IF NOT EXISTS idx_products_created_at ON products (created_at, id) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_price ON products (price_amount, id) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_name ON products (name, id) WHERE deleted_at IS NULL",
+	"CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING gin (name gin_trgm_ops) WHERE deleted_at IS NULL",
+}
+
+// CreateProductIndexes создает индексы каталога товаров. Вызывается после AutoMigrate.
+// Повторный запуск ничего не делает
+func CreateProductIndexes(db *gorm.DB) error {
+	for _, query := range productIndexes {
+		if err := db.Exec(query).Error; err != nil {
+			return fmt.Errorf("create product indexes: %w", err)
+		}
+	}
+	return nil
+}
+
+// refreshCartItemPrices заменяет снимки цен активных позиций корзин текущими ценами товаров
+const refreshCartItemPrices = `UPDATE cart_items SET price_amount = products.price_amount, price_currency = products.price_currency
+	FROM products WHERE cart_items.product_id = products.id AND cart_items.deleted_at IS NULL`
+
+// BackfillCartItemPrices заполняет снимки цен в позициях корзин, добавленных до того,
+// как цена стала сохраняться вместе с позицией. Вызывается один раз, после того как
+// AutoMigrate создал колонки price_amount и price_currency в cart_items
+func BackfillCartItemPrices(db *gorm.DB) error {
+	if err := db.Exec(refreshCartItemPrices).Error; err != nil {
+		return fmt.Errorf("backfill cart item prices: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) repository.PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) Create(payment *domain.Payment) error {
+	return r.db.Create(payment).Error
+}
+
+func (r *paymentRepository) Update(payment *domain.Payment) error {
+	return r.db.Save(payment).Error
+}
+
+func (r *paymentRepository) GetByOrderID(orderID uint) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	err := r.db.Where("order_id = ?", orderID).Order("id").Find(&payments).Error
+	return payments, err
+}
@@ -0,0 +1,289 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	driver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB подключается к базе из TEST_DATABASE_DSN и создает схему
+// Без переменной тест пропускается: проверки уникальных индексов, ON CONFLICT
+// и блокировок имеют смысл только на настоящем postgres
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := gorm.Open(driver.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AllowGuestCarts(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(
+		&domain.User{},
+		&domain.Product{},
+		&domain.Cart{},
+		&domain.CartItem{},
+		&domain.Promotion{},
+		&domain.PromotionRedemption{},
+	); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// createTestUser создает пользователя с уникальным email и удаляет его вместе с корзинами после теста
+func createTestUser(t *testing.T, db *gorm.DB) *domain.User {
+	t.Helper()
+	user := &domain.User{
+		Email:        fmt.Sprintf("race-%d@example.com", time.Now().UnixNano()),
+		Role:         domain.RoleCustomer,
+		PasswordHash: "-",
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM cart_items WHERE cart_id IN (SELECT id FROM carts WHERE user_id = ?)", user.ID)
+		db.Exec("DELETE FROM carts WHERE user_id = ?", user.ID)
+		db.Exec("DELETE FROM promotion_redemptions WHERE user_id = ?", user.ID)
+		db.Unscoped().Delete(user)
+	})
+	return user
+}
+
+// createTestProduct создает товар и удаляет его после теста
+func createTestProduct(t *testing.T, db *gorm.DB, price domain.Money) *domain.Product {
+	t.Helper()
+	product := &domain.Product{Name: "Race test product", Price: price, Stock: 1000}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Unscoped().Delete(product)
+	})
+	return product
+}
+
+func TestAddQuantityConcurrent(t *testing.T) {
+	const workers = 50
+
+	db := openTestDB(t)
+	user := createTestUser(t, db)
+	product := createTestProduct(t, db, domain.NewMoney(10000, "RUB"))
+	uow := NewUnitOfWork(db)
+	owner := domain.CartOwner{UserID: user.ID}
+
+	// Каждая транзакция создает корзину, если ее нет, и добавляет одну единицу товара:
+	// уникальные индексы должны свести все вставки к одной корзине и одной позиции
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- uow.Do(func(repos repository.Repositories) error {
+				cart, err := repos.Carts.GetOrCreate(owner)
+				if err != nil {
+					return err
+				}
+				return repos.CartItems.AddQuantity(&domain.CartItem{
+					CartID:    cart.ID,
+					ProductID: product.ID,
+					Quantity:  1,
+					Price:     product.Price,
+				})
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	var carts int64
+	assert.NoError(t, db.Model(&domain.Cart{}).Where("user_id = ?", user.ID).Count(&carts).Error)
+	assert.Equal(t, int64(1), carts)
+
+	cart, err := NewCartRepository(db).GetByUserID(user.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, cart.Items, 1) {
+		assert.Equal(t, workers, cart.Items[0].Quantity)
+	}
+}
+
+func TestRedeemPerUserLimitConcurrent(t *testing.T) {
+	const workers = 10
+
+	db := openTestDB(t)
+	user := createTestUser(t, db)
+	promotion := &domain.Promotion{
+		Code:              fmt.Sprintf("RACE%d", time.Now().UnixNano()),
+		Type:              domain.PromotionPercentage,
+		PercentOff:        10,
+		UsageLimitPerUser: 1,
+	}
+	assert.NoError(t, db.Create(promotion).Error)
+	t.Cleanup(func() {
+		db.Unscoped().Delete(promotion)
+	})
+	uow := NewUnitOfWork(db)
+
+	// Параллельные оформления одного пользователя: применить купон должно только одно
+	var wg sync.WaitGroup
+	results := make(chan bool, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(orderID uint) {
+			defer wg.Done()
+			err := uow.Do(func(repos repository.Repositories) error {
+				redeemed, err := repos.Promotions.Redeem(&domain.PromotionRedemption{
+					PromotionID: promotion.ID,
+					UserID:      user.ID,
+					OrderID:     orderID,
+				})
+				results <- redeemed
+				return err
+			})
+			assert.NoError(t, err)
+		}(uint(i + 1))
+	}
+	wg.Wait()
+	close(results)
+
+	redeemed := 0
+	for ok := range results {
+		if ok {
+			redeemed++
+		}
+	}
+	assert.Equal(t, 1, redeemed)
+
+	stored, err := NewPromotionRepository(db).GetByCode(promotion.Code)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stored.UsedCount)
+}
+
+func TestUnitOfWorkRollsBackStockAndCoupon(t *testing.T) {
+	db := openTestDB(t)
+	user := createTestUser(t, db)
+	product := createTestProduct(t, db, domain.NewMoney(10000, "RUB"))
+	promotion := &domain.Promotion{
+		Code:       fmt.Sprintf("ROLLBACK%d", time.Now().UnixNano()),
+		Type:       domain.PromotionPercentage,
+		PercentOff: 10,
+	}
+	assert.NoError(t, db.Create(promotion).Error)
+	t.Cleanup(func() {
+		db.Unscoped().Delete(promotion)
+	})
+	uow := NewUnitOfWork(db)
+
+	// Оформление списывает остаток и применяет купон, а затем падает на следующем шаге:
+	// транзакция должна откатиться целиком, включая вложенную транзакцию Redeem
+	failure := errors.New("order insert failed")
+	err := uow.Do(func(repos repository.Repositories) error {
+		ok, err := repos.Products.DecrementStock(product.ID, 3)
+		if err != nil || !ok {
+			t.Fatalf("decrement stock: ok=%v err=%v", ok, err)
+		}
+		redeemed, err := repos.Promotions.Redeem(&domain.PromotionRedemption{
+			PromotionID: promotion.ID,
+			UserID:      user.ID,
+			OrderID:     1,
+		})
+		if err != nil || !redeemed {
+			t.Fatalf("redeem: redeemed=%v err=%v", redeemed, err)
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	stored, err := NewProductRepository(db).GetByID(product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, product.Stock, stored.Stock)
+
+	storedPromotion, err := NewPromotionRepository(db).GetByCode(promotion.Code)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, storedPromotion.UsedCount)
+
+	redemptions, err := NewPromotionRepository(db).CountRedemptions(promotion.ID, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), redemptions)
+}
+
+func TestAddQuantityKeepsSavedPrice(t *testing.T) {
+	db := openTestDB(t)
+	user := createTestUser(t, db)
+	product := createTestProduct(t, db, domain.NewMoney(10000, "RUB"))
+	cart, err := NewCartRepository(db).GetOrCreate(domain.CartOwner{UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := NewCartItemRepository(db)
+
+	// Второе добавление приходит уже с новой ценой товара: снимок позиции остается прежним
+	for _, price := range []domain.Money{product.Price, domain.NewMoney(12000, "RUB")} {
+		assert.NoError(t, items.AddQuantity(&domain.CartItem{CartID: cart.ID, ProductID: product.ID, Quantity: 1, Price: price}))
+	}
+
+	stored, err := items.GetByCartID(cart.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, 2, stored[0].Quantity)
+		assert.Equal(t, product.Price, stored[0].Price)
+	}
+}
+
+func TestAdoptOrphanedUsers(t *testing.T) {
+	db := openTestDB(t)
+
+	// Корзина из времен до появления пользователей: ее user_id не ссылается ни на кого
+	if err := db.Migrator().DropConstraint(&domain.Cart{}, "User"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := db.Migrator().CreateConstraint(&domain.Cart{}, "User"); err != nil {
+			t.Error(err)
+		}
+	})
+	var orphanID uint
+	if err := db.Raw("SELECT COALESCE(MAX(id), 0) + 1000 FROM users").Scan(&orphanID).Error; err != nil {
+		t.Fatal(err)
+	}
+	cart := &domain.Cart{UserID: &orphanID}
+	if err := db.Create(cart).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Unscoped().Delete(cart)
+		db.Unscoped().Delete(&domain.User{}, orphanID)
+	})
+
+	assert.NoError(t, AdoptOrphanedUsers(db))
+	assert.NoError(t, AdoptOrphanedUsers(db))
+
+	var legacy domain.User
+	assert.NoError(t, db.First(&legacy, orphanID).Error)
+	assert.Equal(t, domain.RoleCustomer, legacy.Role)
+	assert.Equal(t, legacyUserPasswordHash, legacy.PasswordHash)
+
+	// Новый пользователь получает id после заглушки
+	user := createTestUser(t, db)
+	assert.Greater(t, user.ID, orphanID)
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"errors"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) repository.PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+func (r *promotionRepository) Create(promotion *domain.Promotion) error {
+	return r.db.Create(promotion).Error
+}
+
+func (r *promotionRepository) GetByCode(code string) (*domain.Promotion, error) {
+	var promotion domain.Promotion
+	err := r.db.Where("code = ?", code).First(&promotion).Error
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+func (r *promotionRepository) GetAll() ([]domain.Promotion, error) {
+	var promotions []domain.Promotion
+	err := r.db.Order("id").Find(&promotions).Error
+	return promotions, err
+}
+
+func (r *promotionRepository) CountRedemptions(promotionID uint, userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.PromotionRedemption{}).
+		Where("promotion_id = ? AND user_id = ?", promotionID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+// Redeem блокирует строку акции до конца транзакции и под блокировкой проверяет оба лимита,
+// поэтому параллельные заказы, в том числе одного пользователя, применяют купон по очереди
+// и видят использования, записанные предыдущими
+func (r *promotionRepository) Redeem(redemption *domain.PromotionRedemption) (bool, error) {
+	redeemed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var promotion domain.Promotion
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promotion, redemption.PromotionID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if promotion.UsageLimit > 0 && promotion.UsedCount >= promotion.UsageLimit {
+			return nil
+		}
+		if promotion.UsageLimitPerUser > 0 {
+			var used int64
+			err := tx.Model(&domain.PromotionRedemption{}).
+				Where("promotion_id = ? AND user_id = ?", promotion.ID, redemption.UserID).
+				Count(&used).Error
+			if err != nil {
+				return err
+			}
+			if used >= int64(promotion.UsageLimitPerUser) {
+				return nil
+			}
+		}
+
+		if err := tx.Model(&promotion).Update("used_count", gorm.Expr("used_count + 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(redemption).Error; err != nil {
+			return err
+		}
+		redeemed = true
+		return nil
+	})
+	return redeemed, err
+}
@@ -1,12 +1,25 @@
 package postgres
 
 import (
+	"fmt"
 	"shopping-cart/internal/domain"
 	"shopping-cart/internal/repository"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// translateError переводит ошибку драйвера в ошибку gorm,
+// например нарушение уникального индекса в gorm.ErrDuplicatedKey
+func translateError(db *gorm.DB, err error) error {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok && err != nil {
+		return translator.Translate(err)
+	}
+	return err
+}
+
 type cartRepository struct {
 	db *gorm.DB
 }
@@ -23,6 +36,14 @@ type productRepository struct {
 	db *gorm.DB
 }
 
+type userRepository struct {
+	db *gorm.DB
+}
+
+// notDeleted - условие частичных уникальных индексов корзин и их позиций:
+// мягко удаленные строки не мешают создать новые
+var notDeleted = clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}}
+
 func NewCartRepository(db *gorm.DB) repository.CartRepository {
 	return &cartRepository{db: db}
 }
@@ -39,6 +60,10 @@ func NewProductRepository(db *gorm.DB) repository.ProductRepository {
 	return &productRepository{db: db}
 }
 
+func NewUserRepository(db *gorm.DB) repository.UserRepository {
+	return &userRepository{db: db}
+}
+
 // Cart Repository Implementation
 func (r *cartRepository) Create(cart *domain.Cart) error {
 	return r.db.Create(cart).Error
@@ -56,14 +81,98 @@ func (r *cartRepository) GetByUserID(userID uint) (*domain.Cart, error) {
 	return &cart, err
 }
 
+// GetByUserIDForUpdate блокирует строку корзины. Параллельная транзакция, удалившая корзину,
+// снимает блокировку при фиксации, и повторная проверка условия исключает удаленную строку
+func (r *cartRepository) GetByUserIDForUpdate(userID uint) (*domain.Cart, error) {
+	var cart domain.Cart
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&cart).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (r *cartRepository) GetByGuestToken(token string) (*domain.Cart, error) {
+	var cart domain.Cart
+	err := r.db.Preload("Items.Product").Where("guest_token = ?", token).First(&cart).Error
+	return &cart, err
+}
+
+func (r *cartRepository) GetByGuestTokenForUpdate(token string) (*domain.Cart, error) {
+	var cart domain.Cart
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").Where("guest_token = ?", token).First(&cart).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// GetOrCreate вставляет корзину с ON CONFLICT DO NOTHING, чтобы параллельные
+// запросы не создали владельцу две корзины, и возвращает сохраненную
+func (r *cartRepository) GetOrCreate(owner domain.CartOwner) (*domain.Cart, error) {
+	cart, conflict := &domain.Cart{UserID: &owner.UserID}, "user_id"
+	if owner.IsGuest() {
+		cart, conflict = &domain.Cart{GuestToken: &owner.GuestToken}, "guest_token"
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: conflict}},
+		TargetWhere: notDeleted,
+		DoNothing:   true,
+	}).Create(cart).Error
+	if err != nil {
+		return nil, err
+	}
+	if owner.IsGuest() {
+		return r.GetByGuestToken(owner.GuestToken)
+	}
+	return r.GetByUserID(owner.UserID)
+}
+
 func (r *cartRepository) Update(cart *domain.Cart) error {
 	return r.db.Save(cart).Error
 }
 
+// SetCouponCode сохраняет код купона корзины, пустая строка снимает купон
+func (r *cartRepository) SetCouponCode(id uint, code string) error {
+	return r.db.Model(&domain.Cart{}).Where("id = ?", id).Update("coupon_code", code).Error
+}
+
 func (r *cartRepository) Delete(id uint) error {
 	return r.db.Delete(&domain.Cart{}, id).Error
 }
 
+// cartLastActivity - время последнего изменения корзины или ее позиций, включая удаление позиций
+const cartLastActivity = `GREATEST(carts.updated_at, (SELECT MAX(GREATEST(cart_items.updated_at, cart_items.deleted_at))
+	FROM cart_items WHERE cart_items.cart_id = carts.id))`
+
+// DeleteInactive мягко удаляет корзины порциями, чтобы не блокировать всю таблицу
+func (r *cartRepository) DeleteInactive(before time.Time, limit int) (int64, error) {
+	result := r.db.Exec(`UPDATE carts SET deleted_at = ? WHERE id IN (
+		SELECT id FROM carts WHERE deleted_at IS NULL AND `+cartLastActivity+` < ? ORDER BY id LIMIT ?)`,
+		time.Now(), before, limit)
+	return result.RowsAffected, result.Error
+}
+
+// GetAbandoned считает стоимость корзин по ценам позиций. Корзина с товарами
+// в разных валютах попадает в отчет отдельной строкой на каждую валюту
+func (r *cartRepository) GetAbandoned(before time.Time, limit int) ([]domain.AbandonedCart, error) {
+	var report []domain.AbandonedCart
+	err := r.db.Raw(`SELECT carts.id AS cart_id, carts.user_id, users.email,
+			SUM(cart_items.quantity) AS item_count,
+			SUM(cart_items.quantity * cart_items.price_amount) AS value_amount,
+			cart_items.price_currency AS value_currency,
+			`+cartLastActivity+` AS last_activity_at
+		FROM carts
+		JOIN cart_items ON cart_items.cart_id = carts.id AND cart_items.deleted_at IS NULL
+		LEFT JOIN users ON users.id = carts.user_id
+		WHERE carts.deleted_at IS NULL AND `+cartLastActivity+` < ?
+		GROUP BY carts.id, users.email, cart_items.price_currency
+		ORDER BY value_amount DESC, carts.id
+		LIMIT ?`, before, limit).Scan(&report).Error
+	return report, err
+}
+
 // CartItem Repository Implementation
 func (r *cartItemRepository) Create(item *domain.CartItem) error {
 	return r.db.Create(item).Error
@@ -81,6 +190,20 @@ func (r *cartItemRepository) GetByCartID(cartID uint) ([]domain.CartItem, error)
 	return items, err
 }
 
+// AddQuantity вставляет позицию с ON CONFLICT DO UPDATE, увеличивая количество
+// в уже существующей позиции без чтения и повторной записи.
+// Снимок цены существующей позиции не меняется: новую цену покупатель подтверждает сам
+func (r *cartItemRepository) AddQuantity(item *domain.CartItem) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "cart_id"}, {Name: "product_id"}},
+		TargetWhere: notDeleted,
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "quantity"}, Value: gorm.Expr("cart_items.quantity + EXCLUDED.quantity")},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("EXCLUDED.updated_at")},
+		},
+	}, clause.Returning{}).Create(item).Error
+}
+
 func (r *cartItemRepository) Update(item *domain.CartItem) error {
 	return r.db.Save(item).Error
 }
@@ -98,9 +221,26 @@ func (r *orderRepository) CreateOrderItem(item *domain.OrderItem) error {
 	return r.db.Create(item).Error
 }
 
+func (r *orderRepository) CreateOrderDiscount(discount *domain.OrderDiscount) error {
+	return r.db.Create(discount).Error
+}
+
 func (r *orderRepository) GetByID(id uint) (*domain.Order, error) {
 	var order domain.Order
-	err := r.db.Preload("Items.Product").First(&order, id).Error
+	byID := func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}
+	err := r.db.Preload("Items.Product").Preload("Discounts").Preload("Payments", byID).Preload("Refunds", byID).First(&order, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetByIDForUpdate загружает заказ, блокируя его строку до конца транзакции
+func (r *orderRepository) GetByIDForUpdate(id uint) (*domain.Order, error) {
+	var order domain.Order
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&order, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +249,7 @@ func (r *orderRepository) GetByID(id uint) (*domain.Order, error) {
 
 func (r *orderRepository) GetByUserID(userID uint) ([]domain.Order, error) {
 	var orders []domain.Order
-	err := r.db.Preload("Items.Product").Where("user_id = ?", userID).Find(&orders).Error
+	err := r.db.Preload("Items.Product").Preload("Discounts").Where("user_id = ?", userID).Find(&orders).Error
 	return orders, err
 }
 
@@ -117,8 +257,41 @@ func (r *orderRepository) Update(order *domain.Order) error {
 	return r.db.Save(order).Error
 }
 
-func (r *orderRepository) UpdateStatus(id uint, status string) error {
-	return r.db.Model(&domain.Order{}).Where("id = ?", id).Update("status", status).Error
+func (r *orderRepository) UpdateStatus(id uint, status domain.OrderStatus) error {
+	result := r.db.Model(&domain.Order{}).Where("id = ?", id).Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetCancellation сохраняет причину и время отмены заказа
+func (r *orderRepository) SetCancellation(id uint, reason string, cancelledAt time.Time) error {
+	result := r.db.Model(&domain.Order{}).Where("id = ?", id).Updates(map[string]any{
+		"cancellation_reason": reason,
+		"cancelled_at":        cancelledAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *orderRepository) AddStatusHistory(entry *domain.OrderStatusHistory) error {
+	return r.db.Create(entry).Error
+}
+
+// GetStatusHistory возвращает журнал статусов заказа в хронологическом порядке
+func (r *orderRepository) GetStatusHistory(orderID uint) ([]domain.OrderStatusHistory, error) {
+	var history []domain.OrderStatusHistory
+	err := r.db.Where("order_id = ?", orderID).Order("created_at, id").Find(&history).Error
+	return history, err
 }
 
 // Product Repository Implementation
@@ -132,16 +305,152 @@ func (r *productRepository) GetByID(id uint) (*domain.Product, error) {
 	return &product, err
 }
 
-func (r *productRepository) GetAll() ([]domain.Product, error) {
+// List возвращает страницу каталога: товары, подходящие под фильтр, в порядке query.Sort.
+// С курсором выборка начинается сразу после товара из курсора, иначе - со смещения query.Offset.
+// Порядок дополняется id, чтобы товары с одинаковой ценой или названием не терялись между страницами
+func (r *productRepository) List(query domain.ProductQuery) ([]domain.Product, error) {
+	db := filterProducts(r.db.Model(&domain.Product{}), query.ProductFilter)
+
+	var key, direction string
+	switch query.Sort {
+	case domain.ProductSortPriceAsc:
+		key, direction = "price_amount", "ASC"
+	case domain.ProductSortPriceDesc:
+		key, direction = "price_amount", "DESC"
+	case domain.ProductSortName:
+		key, direction = "name", "ASC"
+	default:
+		key, direction = "created_at", "DESC"
+	}
+	if after := query.After; after != nil {
+		var value any
+		switch query.Sort {
+		case domain.ProductSortPriceAsc, domain.ProductSortPriceDesc:
+			value = after.Price
+		case domain.ProductSortName:
+			value = after.Name
+		default:
+			value = after.CreatedAt
+		}
+		comparison := ">"
+		if direction == "DESC" {
+			comparison = "<"
+		}
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", key, comparison), value, after.ID)
+	}
+
 	var products []domain.Product
-	err := r.db.Find(&products).Error
+	err := db.Order(fmt.Sprintf("%[1]s %[2]s, id %[2]s", key, direction)).
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Find(&products).Error
 	return products, err
 }
 
+// Count возвращает число товаров, подходящих под фильтр
+func (r *productRepository) Count(filter domain.ProductFilter) (int64, error) {
+	var total int64
+	err := filterProducts(r.db.Model(&domain.Product{}), filter).Count(&total).Error
+	return total, err
+}
+
+// filterProducts добавляет к запросу условия фильтра каталога
+func filterProducts(db *gorm.DB, filter domain.ProductFilter) *gorm.DB {
+	if filter.Name != "" {
+		db = db.Where("name ILIKE ?", "%"+escapeLike(filter.Name)+"%")
+	}
+	if filter.Category != "" {
+		db = db.Where("category = ?", filter.Category)
+	}
+	if filter.MinPrice != nil {
+		db = db.Where("price_currency = ? AND price_amount >= ?", filter.MinPrice.Currency, filter.MinPrice.Amount)
+	}
+	if filter.MaxPrice != nil {
+		db = db.Where("price_currency = ? AND price_amount <= ?", filter.MaxPrice.Currency, filter.MaxPrice.Amount)
+	}
+	if filter.InStock {
+		db = db.Where("stock > 0")
+	}
+	return db
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE, чтобы строка искалась буквально
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// Update сохраняет товар без изменения остатка:
+// остаток меняется только через SetStock/DecrementStock/IncrementStock,
+// чтобы не затереть параллельные списания при оформлении заказов
 func (r *productRepository) Update(product *domain.Product) error {
-	return r.db.Save(product).Error
+	return r.db.Omit("stock").Save(product).Error
 }
 
 func (r *productRepository) Delete(id uint) error {
-	return r.db.Delete(&domain.Product{}, id).Error
+	result := r.db.Delete(&domain.Product{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *productRepository) SetStock(id uint, stock int) error {
+	result := r.db.Model(&domain.Product{}).Where("id = ?", id).Update("stock", stock)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DecrementStock атомарно списывает quantity единиц товара
+// Возвращает false, если на складе недостаточно товара
+func (r *productRepository) DecrementStock(id uint, quantity int) (bool, error) {
+	result := r.db.Model(&domain.Product{}).
+		Where("id = ? AND stock >= ?", id, quantity).
+		UpdateColumn("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// IncrementStock возвращает quantity единиц товара на склад
+func (r *productRepository) IncrementStock(id uint, quantity int) error {
+	return r.db.Model(&domain.Product{}).
+		Where("id = ?", id).
+		UpdateColumn("stock", gorm.Expr("stock + ?", quantity)).Error
+}
+
+// User Repository Implementation
+// Create сохраняет пользователя; занятый email возвращается как gorm.ErrDuplicatedKey
+func (r *userRepository) Create(user *domain.User) error {
+	return translateError(r.db, r.db.Create(user).Error)
+}
+
+func (r *userRepository) GetByID(id uint) (*domain.User, error) {
+	var user domain.User
+	err := r.db.First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByEmail(email string) (*domain.User, error) {
+	var user domain.User
+	err := r.db.Where("email = ?", email).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Update(user *domain.User) error {
+	return r.db.Save(user).Error
 }
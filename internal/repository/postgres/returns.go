@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type returnRepository struct {
+	db *gorm.DB
+}
+
+func NewReturnRepository(db *gorm.DB) repository.ReturnRepository {
+	return &returnRepository{db: db}
+}
+
+// Create сохраняет заявку вместе с ее позициями
+func (r *returnRepository) Create(orderReturn *domain.OrderReturn) error {
+	return r.db.Create(orderReturn).Error
+}
+
+func (r *returnRepository) GetByID(id uint) (*domain.OrderReturn, error) {
+	var orderReturn domain.OrderReturn
+	err := r.db.Preload("Items").First(&orderReturn, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &orderReturn, nil
+}
+
+func (r *returnRepository) GetByOrderID(orderID uint) ([]domain.OrderReturn, error) {
+	var returns []domain.OrderReturn
+	err := r.db.Preload("Items").Where("order_id = ?", orderID).Order("id").Find(&returns).Error
+	return returns, err
+}
+
+// Update сохраняет решение по заявке; позиции заявки после создания не меняются
+func (r *returnRepository) Update(orderReturn *domain.OrderReturn) error {
+	return r.db.Omit("Items").Save(orderReturn).Error
+}
+
+func (r *returnRepository) CreateRefund(refund *domain.Refund) error {
+	return r.db.Create(refund).Error
+}
+
+func (r *returnRepository) UpdateRefund(refund *domain.Refund) error {
+	return r.db.Save(refund).Error
+}
+
+func (r *returnRepository) GetRefundsByOrderID(orderID uint) ([]domain.Refund, error) {
+	var refunds []domain.Refund
+	err := r.db.Where("order_id = ?", orderID).Order("id").Find(&refunds).Error
+	return refunds, err
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"shopping-cart/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) repository.UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+// Do выполняет fn в транзакции базы данных
+// Все репозитории, переданные в fn, используют одну и ту же транзакцию
+func (u *unitOfWork) Do(fn func(repos repository.Repositories) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(repository.Repositories{
+			Carts:      NewCartRepository(tx),
+			CartItems:  NewCartItemRepository(tx),
+			Orders:     NewOrderRepository(tx),
+			Products:   NewProductRepository(tx),
+			Promotions: NewPromotionRepository(tx),
+			Payments:   NewPaymentRepository(tx),
+			Returns:    NewReturnRepository(tx),
+			Wishlists:  NewWishlistRepository(tx),
+		})
+	})
+}
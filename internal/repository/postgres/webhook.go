@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type webhookEventRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookEventRepository(db *gorm.DB) repository.WebhookEventRepository {
+	return &webhookEventRepository{db: db}
+}
+
+// Claim вставляет событие с ON CONFLICT DO NOTHING, чтобы из параллельных
+// доставок одного события его получила только одна
+func (r *webhookEventRepository) Claim(event *domain.WebhookEvent) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}},
+		DoNothing: true,
+	}).Create(event)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *webhookEventRepository) Release(eventID string) error {
+	return r.db.Where("event_id = ?", eventID).Delete(&domain.WebhookEvent{}).Error
+}
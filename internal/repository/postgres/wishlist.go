@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type wishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) repository.WishlistRepository {
+	return &wishlistRepository{db: db}
+}
+
+// GetOrCreate вставляет список с ON CONFLICT DO NOTHING, чтобы параллельные
+// запросы не создали пользователю два списка, и возвращает сохраненный
+func (r *wishlistRepository) GetOrCreate(userID uint) (*domain.Wishlist, error) {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoNothing: true,
+	}).Create(&domain.Wishlist{UserID: userID}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var wishlist domain.Wishlist
+	err = r.db.Preload("Items.Product").Where("user_id = ?", userID).First(&wishlist).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+func (r *wishlistRepository) GetBySlug(slug string) (*domain.Wishlist, error) {
+	var wishlist domain.Wishlist
+	err := r.db.Preload("Items.Product").Where("slug = ?", slug).First(&wishlist).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+func (r *wishlistRepository) SetSlug(id uint, slug *string) error {
+	return r.db.Model(&domain.Wishlist{}).Where("id = ?", id).Update("slug", slug).Error
+}
+
+func (r *wishlistRepository) GetItem(id uint) (*domain.SavedItem, error) {
+	var item domain.SavedItem
+	err := r.db.Preload("Product").First(&item, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// AddItem вставляет позицию с ON CONFLICT DO UPDATE, увеличивая количество
+// в уже существующей позиции без чтения и повторной записи
+func (r *wishlistRepository) AddItem(item *domain.SavedItem) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "wishlist_id"}, {Name: "product_id"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "quantity"}, Value: gorm.Expr("LEAST(saved_items.quantity + EXCLUDED.quantity, ?)", domain.MaxLineQuantity)},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("EXCLUDED.updated_at")},
+		},
+	}, clause.Returning{}).Create(item).Error
+}
+
+func (r *wishlistRepository) DeleteItem(id uint) error {
+	result := r.db.Delete(&domain.SavedItem{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
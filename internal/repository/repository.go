@@ -2,6 +2,7 @@ package repository
 
 import (
 	"shopping-cart/internal/domain"
+	"time"
 )
 
 // CartRepository определяет методы для работы с корзинами
@@ -9,8 +10,22 @@ type CartRepository interface {
 	Create(cart *domain.Cart) error
 	GetByID(id uint) (*domain.Cart, error)
 	GetByUserID(userID uint) (*domain.Cart, error)
+	// GetByUserIDForUpdate загружает корзину пользователя без позиций, блокируя ее строку до конца транзакции
+	GetByUserIDForUpdate(userID uint) (*domain.Cart, error)
+	GetByGuestToken(token string) (*domain.Cart, error)
+	// GetByGuestTokenForUpdate загружает гостевую корзину, блокируя ее строку до конца транзакции
+	GetByGuestTokenForUpdate(token string) (*domain.Cart, error)
+	// GetOrCreate возвращает корзину пользователя или гостя, атомарно создавая ее при отсутствии
+	GetOrCreate(owner domain.CartOwner) (*domain.Cart, error)
 	Update(cart *domain.Cart) error
+	SetCouponCode(id uint, code string) error
 	Delete(id uint) error
+	// DeleteInactive удаляет до limit корзин, которые не менялись с момента before,
+	// и возвращает количество удаленных
+	DeleteInactive(before time.Time, limit int) (int64, error)
+	// GetAbandoned возвращает до limit корзин с товарами, которые не менялись с момента before,
+	// начиная с самых дорогих
+	GetAbandoned(before time.Time, limit int) ([]domain.AbandonedCart, error)
 }
 
 // CartItemRepository определяет методы для работы с элементами корзины
@@ -18,6 +33,10 @@ type CartItemRepository interface {
 	Create(item *domain.CartItem) error
 	GetByID(id uint) (*domain.CartItem, error)
 	GetByCartID(cartID uint) ([]domain.CartItem, error)
+	// AddQuantity атомарно добавляет item.Quantity единиц товара в корзину: создает позицию
+	// или увеличивает количество в существующей, сохраняя ее снимок цены.
+	// После вызова item содержит сохраненную позицию с итоговым количеством
+	AddQuantity(item *domain.CartItem) error
 	Update(item *domain.CartItem) error
 	Delete(id uint) error
 }
@@ -27,16 +46,125 @@ type OrderRepository interface {
 	Create(order *domain.Order) error
 	GetByID(id uint) (*domain.Order, error)
 	GetByUserID(userID uint) ([]domain.Order, error)
+	GetByIDForUpdate(id uint) (*domain.Order, error)
 	Update(order *domain.Order) error
-	UpdateStatus(id uint, status string) error
+	UpdateStatus(id uint, status domain.OrderStatus) error
+	SetCancellation(id uint, reason string, cancelledAt time.Time) error
 	CreateOrderItem(item *domain.OrderItem) error
+	CreateOrderDiscount(discount *domain.OrderDiscount) error
+	AddStatusHistory(entry *domain.OrderStatusHistory) error
+	GetStatusHistory(orderID uint) ([]domain.OrderStatusHistory, error)
 }
 
 // ProductRepository определяет методы для работы с товарами
 type ProductRepository interface {
 	Create(product *domain.Product) error
 	GetByID(id uint) (*domain.Product, error)
-	GetAll() ([]domain.Product, error)
+	List(query domain.ProductQuery) ([]domain.Product, error)
+	Count(filter domain.ProductFilter) (int64, error)
 	Update(product *domain.Product) error
 	Delete(id uint) error
+	SetStock(id uint, stock int) error
+	DecrementStock(id uint, quantity int) (bool, error)
+	IncrementStock(id uint, quantity int) error
+}
+
+// UserRepository определяет методы для работы с пользователями
+type UserRepository interface {
+	Create(user *domain.User) error
+	GetByID(id uint) (*domain.User, error)
+	GetByEmail(email string) (*domain.User, error)
+	Update(user *domain.User) error
+}
+
+// AddressRepository определяет методы для работы с адресной книгой
+type AddressRepository interface {
+	Create(address *domain.Address) error
+	GetByID(id uint) (*domain.Address, error)
+	GetByUserID(userID uint) ([]domain.Address, error)
+	Update(address *domain.Address) error
+	Delete(id uint) error
+}
+
+// PaymentRepository определяет методы для работы с платежами
+type PaymentRepository interface {
+	Create(payment *domain.Payment) error
+	Update(payment *domain.Payment) error
+	GetByOrderID(orderID uint) ([]domain.Payment, error)
+}
+
+// ReturnRepository определяет методы для работы с возвратами
+type ReturnRepository interface {
+	Create(orderReturn *domain.OrderReturn) error
+	GetByID(id uint) (*domain.OrderReturn, error)
+	GetByOrderID(orderID uint) ([]domain.OrderReturn, error)
+	Update(orderReturn *domain.OrderReturn) error
+	CreateRefund(refund *domain.Refund) error
+	UpdateRefund(refund *domain.Refund) error
+	GetRefundsByOrderID(orderID uint) ([]domain.Refund, error)
+}
+
+// WebhookEventRepository определяет методы для учета обработанных событий вебхуков
+type WebhookEventRepository interface {
+	// Claim сохраняет событие, если оно еще не было сохранено
+	// Возвращает false, если событие с таким EventID уже есть
+	Claim(event *domain.WebhookEvent) (bool, error)
+	// Release удаляет событие, чтобы его можно было обработать повторно
+	Release(eventID string) error
+}
+
+// IdempotencyKeyRepository определяет методы для хранения ключей идемпотентности
+type IdempotencyKeyRepository interface {
+	// Claim сохраняет ключ, если у пользователя еще нет такого ключа
+	// Возвращает false, если ключ уже занят
+	Claim(key *domain.IdempotencyKey) (bool, error)
+	GetByKey(userID uint, key string) (*domain.IdempotencyKey, error)
+	// Complete сохраняет ответ на запрос с ключом key.UserID и key.Key
+	Complete(key *domain.IdempotencyKey) error
+	// Release удаляет ключ, чтобы запрос с ним можно было выполнить заново
+	Release(userID uint, key string) error
+}
+
+// PromotionRepository определяет методы для работы с акциями
+type PromotionRepository interface {
+	Create(promotion *domain.Promotion) error
+	GetByCode(code string) (*domain.Promotion, error)
+	GetAll() ([]domain.Promotion, error)
+	CountRedemptions(promotionID uint, userID uint) (int64, error)
+	// Redeem учитывает использование акции, если не исчерпаны общий лимит и лимит пользователя
+	// Возвращает false, если лимит исчерпан
+	Redeem(redemption *domain.PromotionRedemption) (bool, error)
+}
+
+// WishlistRepository определяет методы для работы со списками отложенных товаров
+type WishlistRepository interface {
+	// GetOrCreate возвращает список пользователя, атомарно создавая его при отсутствии
+	GetOrCreate(userID uint) (*domain.Wishlist, error)
+	GetBySlug(slug string) (*domain.Wishlist, error)
+	// SetSlug публикует список по адресу slug, nil снимает публикацию
+	SetSlug(id uint, slug *string) error
+	GetItem(id uint) (*domain.SavedItem, error)
+	// AddItem атомарно добавляет item.Quantity единиц товара в список: создает позицию
+	// или увеличивает количество в существующей, но не больше domain.MaxLineQuantity.
+	// После вызова item содержит сохраненную позицию с итоговым количеством
+	AddItem(item *domain.SavedItem) error
+	DeleteItem(id uint) error
+}
+
+// Repositories - набор репозиториев, работающих в рамках одной транзакции
+type Repositories struct {
+	Carts      CartRepository
+	CartItems  CartItemRepository
+	Orders     OrderRepository
+	Products   ProductRepository
+	Promotions PromotionRepository
+	Payments   PaymentRepository
+	Returns    ReturnRepository
+	Wishlists  WishlistRepository
+}
+
+// UnitOfWork выполняет операции над несколькими репозиториями атомарно
+// Если fn возвращает ошибку, все изменения откатываются
+type UnitOfWork interface {
+	Do(fn func(repos Repositories) error) error
 }
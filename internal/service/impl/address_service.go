@@ -0,0 +1,78 @@
+package impl
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+)
+
+// addressService реализует интерфейс AddressService
+type addressService struct {
+	addressRepo repository.AddressRepository
+}
+
+// NewAddressService создает новый экземпляр AddressService
+func NewAddressService(addressRepo repository.AddressRepository) service.AddressService {
+	return &addressService{
+		addressRepo: addressRepo,
+	}
+}
+
+// GetAddresses возвращает адресную книгу пользователя
+func (s *addressService) GetAddresses(userID uint) ([]domain.Address, error) {
+	return s.addressRepo.GetByUserID(userID)
+}
+
+// CreateAddress добавляет адрес в адресную книгу пользователя
+func (s *addressService) CreateAddress(userID uint, address *domain.Address) error {
+	address.PostalAddress.Normalize()
+	if err := address.PostalAddress.Validate(); err != nil {
+		return err
+	}
+	address.ID = 0
+	address.UserID = userID
+	return s.addressRepo.Create(address)
+}
+
+// UpdateAddress обновляет адрес с идентификатором address.ID
+func (s *addressService) UpdateAddress(userID uint, address *domain.Address) error {
+	address.PostalAddress.Normalize()
+	if err := address.PostalAddress.Validate(); err != nil {
+		return err
+	}
+
+	existing, err := getOwnedAddress(s.addressRepo, userID, address.ID)
+	if err != nil {
+		return err
+	}
+	existing.Label = address.Label
+	existing.PostalAddress = address.PostalAddress
+
+	if err := s.addressRepo.Update(existing); err != nil {
+		return err
+	}
+	*address = *existing
+	return nil
+}
+
+// DeleteAddress удаляет адрес из адресной книги
+// Заказы хранят снимок адреса, поэтому удаление на них не влияет
+func (s *addressService) DeleteAddress(userID uint, addressID uint) error {
+	if _, err := getOwnedAddress(s.addressRepo, userID, addressID); err != nil {
+		return err
+	}
+	return wrapNotFound(s.addressRepo.Delete(addressID), "address")
+}
+
+// getOwnedAddress возвращает адрес пользователя
+// Чужой адрес неотличим от несуществующего, чтобы не раскрывать чужие данные
+func getOwnedAddress(addresses repository.AddressRepository, userID uint, addressID uint) (*domain.Address, error) {
+	address, err := addresses.GetByID(addressID)
+	if err != nil {
+		return nil, wrapNotFound(err, "address")
+	}
+	if address.UserID != userID {
+		return nil, domain.NewNotFoundError("address", nil)
+	}
+	return address, nil
+}
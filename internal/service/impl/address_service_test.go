@@ -0,0 +1,108 @@
+package impl
+
+import (
+	"shopping-cart/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockAddressRepository - мок репозитория адресов
+type MockAddressRepository struct {
+	mock.Mock
+}
+
+func (m *MockAddressRepository) Create(address *domain.Address) error {
+	args := m.Called(address)
+	return args.Error(0)
+}
+
+func (m *MockAddressRepository) GetByID(id uint) (*domain.Address, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Address), args.Error(1)
+}
+
+func (m *MockAddressRepository) GetByUserID(userID uint) ([]domain.Address, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Address), args.Error(1)
+}
+
+func (m *MockAddressRepository) Update(address *domain.Address) error {
+	args := m.Called(address)
+	return args.Error(0)
+}
+
+func (m *MockAddressRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func validAddress() domain.PostalAddress {
+	return domain.PostalAddress{
+		Recipient:  "Иван Иванов",
+		Line1:      "ул. Ленина, 1",
+		City:       "Москва",
+		PostalCode: "101000",
+		Country:    " ru ",
+	}
+}
+
+func TestCreateAddress(t *testing.T) {
+	tests := []struct {
+		name          string
+		address       domain.PostalAddress
+		expectedError error
+	}{
+		{name: "Успешное добавление", address: validAddress()},
+		{name: "Не указан город", address: domain.PostalAddress{Recipient: "Иван", Line1: "ул. Ленина, 1", PostalCode: "101000", Country: "RU"}, expectedError: domain.ErrValidation},
+		{name: "Неверный код страны", address: func() domain.PostalAddress { a := validAddress(); a.Country = "Russia"; return a }(), expectedError: domain.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAddressRepo := new(MockAddressRepository)
+			addressService := NewAddressService(mockAddressRepo)
+			mockAddressRepo.On("Create", mock.MatchedBy(func(a *domain.Address) bool {
+				return a.UserID == 1 && a.Country == "RU"
+			})).Return(nil).Maybe()
+
+			address := &domain.Address{UserID: 99, PostalAddress: tt.address}
+			err := addressService.CreateAddress(1, address)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				mockAddressRepo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			mockAddressRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddressOwnership(t *testing.T) {
+	mockAddressRepo := new(MockAddressRepository)
+	addressService := NewAddressService(mockAddressRepo)
+
+	mockAddressRepo.On("GetByID", uint(5)).Return(&domain.Address{ID: 5, UserID: 2, PostalAddress: validAddress()}, nil)
+	mockAddressRepo.On("GetByID", uint(6)).Return(nil, gorm.ErrRecordNotFound)
+
+	err := addressService.UpdateAddress(1, &domain.Address{ID: 5, PostalAddress: validAddress()})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	err = addressService.DeleteAddress(1, 5)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	err = addressService.DeleteAddress(1, 6)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	mockAddressRepo.AssertNotCalled(t, "Update", mock.Anything)
+	mockAddressRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
@@ -0,0 +1,55 @@
+package impl
+
+import (
+	"fmt"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+	"time"
+)
+
+// expireCartsBatchSize - количество корзин, удаляемых одним запросом
+const expireCartsBatchSize = 500
+
+// maxAbandonedCartsLimit - максимальное количество строк отчета о брошенных корзинах
+const maxAbandonedCartsLimit = 500
+
+// cartExpiryService реализует интерфейс CartExpiryService
+type cartExpiryService struct {
+	cartRepo repository.CartRepository
+	ttl      time.Duration
+}
+
+// NewCartExpiryService создает новый экземпляр CartExpiryService
+// Корзины, которые не менялись дольше ttl, считаются просроченными
+func NewCartExpiryService(cartRepo repository.CartRepository, ttl time.Duration) service.CartExpiryService {
+	return &cartExpiryService{
+		cartRepo: cartRepo,
+		ttl:      ttl,
+	}
+}
+
+// ExpireCarts удаляет просроченные корзины порциями, пока они не закончатся
+func (s *cartExpiryService) ExpireCarts() (int64, error) {
+	before := time.Now().Add(-s.ttl)
+
+	var total int64
+	for {
+		deleted, err := s.cartRepo.DeleteInactive(before, expireCartsBatchSize)
+		total += deleted
+		if err != nil || deleted < expireCartsBatchSize {
+			return total, err
+		}
+	}
+}
+
+// GetAbandonedCarts возвращает брошенные корзины, начиная с самых дорогих
+func (s *cartExpiryService) GetAbandonedCarts(idle time.Duration, limit int) ([]domain.AbandonedCart, error) {
+	if idle <= 0 {
+		return nil, domain.NewValidationError("idle period must be positive")
+	}
+	if limit <= 0 || limit > maxAbandonedCartsLimit {
+		return nil, domain.NewValidationError(fmt.Sprintf("limit must be between 1 and %d", maxAbandonedCartsLimit))
+	}
+	return s.cartRepo.GetAbandoned(time.Now().Add(-idle), limit)
+}
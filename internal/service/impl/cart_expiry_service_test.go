@@ -0,0 +1,106 @@
+package impl
+
+import (
+	"errors"
+	"shopping-cart/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExpireCarts(t *testing.T) {
+	tests := []struct {
+		name          string
+		batches       []int64
+		batchError    error
+		expected      int64
+		expectedError error
+	}{
+		{name: "Просроченных корзин нет", batches: []int64{0}},
+		{name: "Меньше одной порции", batches: []int64{12}, expected: 12},
+		{name: "Удаление порциями до конца", batches: []int64{expireCartsBatchSize, expireCartsBatchSize, 3}, expected: 2*expireCartsBatchSize + 3},
+		{
+			name:          "Ошибка посреди удаления",
+			batches:       []int64{expireCartsBatchSize, 0},
+			batchError:    errors.New("db is down"),
+			expected:      expireCartsBatchSize,
+			expectedError: errors.New("db is down"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			expiryService := NewCartExpiryService(mockCartRepo, 24*time.Hour)
+
+			// Граница считается от текущего времени на ttl назад
+			before := mock.MatchedBy(func(before time.Time) bool {
+				age := time.Since(before)
+				return age >= 24*time.Hour && age < 25*time.Hour
+			})
+			for i, deleted := range tt.batches {
+				var err error
+				if i == len(tt.batches)-1 {
+					err = tt.batchError
+				}
+				mockCartRepo.On("DeleteInactive", before, expireCartsBatchSize).Return(deleted, err).Once()
+			}
+
+			expired, err := expiryService.ExpireCarts()
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, expired)
+			mockCartRepo.AssertNumberOfCalls(t, "DeleteInactive", len(tt.batches))
+		})
+	}
+}
+
+func TestGetAbandonedCarts(t *testing.T) {
+	report := []domain.AbandonedCart{
+		{CartID: 3, UserID: uintPtr(1), Email: "buyer@example.com", ItemCount: 2, Value: domain.NewMoney(20000, "RUB")},
+	}
+
+	tests := []struct {
+		name          string
+		idle          time.Duration
+		limit         int
+		expectQuery   bool
+		expectedError error
+	}{
+		{name: "Отчет за сутки", idle: 24 * time.Hour, limit: 100, expectQuery: true},
+		{name: "Нулевой период", idle: 0, limit: 100, expectedError: domain.ErrValidation},
+		{name: "Нулевой лимит", idle: time.Hour, limit: 0, expectedError: domain.ErrValidation},
+		{name: "Слишком большой лимит", idle: time.Hour, limit: maxAbandonedCartsLimit + 1, expectedError: domain.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			expiryService := NewCartExpiryService(mockCartRepo, 30*24*time.Hour)
+
+			mockCartRepo.On("GetAbandoned", mock.MatchedBy(func(before time.Time) bool {
+				age := time.Since(before)
+				return age >= tt.idle && age < tt.idle+time.Minute
+			}), tt.limit).Return(report, nil).Maybe()
+
+			carts, err := expiryService.GetAbandonedCarts(tt.idle, tt.limit)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, carts)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, report, carts)
+			}
+			if tt.expectQuery {
+				mockCartRepo.AssertExpectations(t)
+			} else {
+				mockCartRepo.AssertNotCalled(t, "GetAbandoned", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
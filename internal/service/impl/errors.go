@@ -0,0 +1,17 @@
+package impl
+
+import (
+	"errors"
+	"shopping-cart/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// wrapNotFound переводит gorm.ErrRecordNotFound в доменную ошибку NotFound,
+// остальные ошибки возвращает без изменений
+func wrapNotFound(err error, resource string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.NewNotFoundError(resource, err)
+	}
+	return err
+}
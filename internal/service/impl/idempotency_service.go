@@ -0,0 +1,95 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxIdempotencyKeyLength - максимальная длина ключа идемпотентности
+const maxIdempotencyKeyLength = 255
+
+// idempotencyService реализует интерфейс IdempotencyService
+type idempotencyService struct {
+	keyRepo repository.IdempotencyKeyRepository
+	ttl     time.Duration
+}
+
+// NewIdempotencyService создает новый экземпляр IdempotencyService
+// Ключи старше ttl считаются истекшими и могут быть использованы заново
+func NewIdempotencyService(keyRepo repository.IdempotencyKeyRepository, ttl time.Duration) service.IdempotencyService {
+	return &idempotencyService{
+		keyRepo: keyRepo,
+		ttl:     ttl,
+	}
+}
+
+// Begin закрепляет ключ за запросом или возвращает сохраненный ответ на него
+// Повтор с другим запросом и повтор до завершения первого запроса отклоняются
+func (s *idempotencyService) Begin(userID uint, key string, fingerprint string) (*domain.IdempotencyKey, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return nil, domain.NewValidationError(fmt.Sprintf("idempotency key must be 1 to %d characters long", maxIdempotencyKeyLength))
+	}
+
+	claimed, err := s.claim(userID, key, fingerprint)
+	if err != nil || claimed {
+		return nil, err
+	}
+
+	existing, err := s.keyRepo.GetByKey(userID, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Ключ освободили между попыткой вставки и чтением
+		return nil, service.ErrIdempotencyKeyInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Since(existing.CreatedAt) > s.ttl {
+		if err := s.keyRepo.Release(userID, key); err != nil {
+			return nil, err
+		}
+		if claimed, err = s.claim(userID, key, fingerprint); err != nil || claimed {
+			return nil, err
+		}
+		return nil, service.ErrIdempotencyKeyInProgress
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return nil, service.ErrIdempotencyKeyReused
+	}
+	if !existing.IsCompleted() {
+		return nil, service.ErrIdempotencyKeyInProgress
+	}
+	return existing, nil
+}
+
+// Complete сохраняет ответ на выполненный запрос для последующих повторов
+func (s *idempotencyService) Complete(userID uint, key string, statusCode int, contentType string, body []byte) error {
+	return s.keyRepo.Complete(&domain.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        body,
+	})
+}
+
+// Release освобождает ключ, если запрос не удалось выполнить, чтобы его можно было повторить
+func (s *idempotencyService) Release(userID uint, key string) error {
+	return s.keyRepo.Release(userID, key)
+}
+
+// claim пытается закрепить ключ за текущим запросом
+func (s *idempotencyService) claim(userID uint, key string, fingerprint string) (bool, error) {
+	return s.keyRepo.Claim(&domain.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+	})
+}
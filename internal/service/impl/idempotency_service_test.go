@@ -0,0 +1,135 @@
+package impl
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockIdempotencyKeyRepository - мок репозитория ключей идемпотентности
+type MockIdempotencyKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyKeyRepository) Claim(key *domain.IdempotencyKey) (bool, error) {
+	args := m.Called(key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyKeyRepository) GetByKey(userID uint, key string) (*domain.IdempotencyKey, error) {
+	args := m.Called(userID, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.IdempotencyKey), args.Error(1)
+}
+
+func (m *MockIdempotencyKeyRepository) Complete(key *domain.IdempotencyKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyKeyRepository) Release(userID uint, key string) error {
+	args := m.Called(userID, key)
+	return args.Error(0)
+}
+
+func TestIdempotencyBegin(t *testing.T) {
+	completed := &domain.IdempotencyKey{
+		UserID:      1,
+		Key:         "checkout-1",
+		Fingerprint: "abc",
+		StatusCode:  201,
+		ContentType: "application/json",
+		Body:        []byte(`{"id":5}`),
+		CreatedAt:   time.Now().Add(-time.Minute),
+	}
+
+	tests := []struct {
+		name          string
+		key           string
+		claims        []bool
+		existing      *domain.IdempotencyKey
+		lookupError   error
+		expectRelease bool
+		expectReplay  bool
+		expectedError error
+	}{
+		{name: "Новый ключ", key: "checkout-1", claims: []bool{true}},
+		{name: "Повтор выполненного запроса", key: "checkout-1", claims: []bool{false}, existing: completed, expectReplay: true},
+		{
+			name:          "Тот же ключ с другим телом",
+			key:           "checkout-1",
+			claims:        []bool{false},
+			existing:      &domain.IdempotencyKey{Fingerprint: "other", StatusCode: 201, CreatedAt: time.Now()},
+			expectedError: service.ErrIdempotencyKeyReused,
+		},
+		{
+			name:          "Первый запрос еще выполняется",
+			key:           "checkout-1",
+			claims:        []bool{false},
+			existing:      &domain.IdempotencyKey{Fingerprint: "abc", CreatedAt: time.Now()},
+			expectedError: service.ErrIdempotencyKeyInProgress,
+		},
+		{
+			name:          "Ключ освобожден параллельным запросом",
+			key:           "checkout-1",
+			claims:        []bool{false},
+			lookupError:   gorm.ErrRecordNotFound,
+			expectedError: service.ErrIdempotencyKeyInProgress,
+		},
+		{
+			name:          "Истекший ключ используется заново",
+			key:           "checkout-1",
+			claims:        []bool{false, true},
+			existing:      &domain.IdempotencyKey{Fingerprint: "other", StatusCode: 201, CreatedAt: time.Now().Add(-48 * time.Hour)},
+			expectRelease: true,
+		},
+		{name: "Пустой ключ", key: "", expectedError: domain.ErrValidation},
+		{name: "Слишком длинный ключ", key: string(make([]byte, maxIdempotencyKeyLength+1)), expectedError: domain.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockKeyRepo := new(MockIdempotencyKeyRepository)
+			idempotencyService := NewIdempotencyService(mockKeyRepo, 24*time.Hour)
+
+			matchKey := mock.MatchedBy(func(k *domain.IdempotencyKey) bool {
+				return k.UserID == 1 && k.Key == tt.key && k.Fingerprint == "abc" && !k.IsCompleted()
+			})
+			for _, claimed := range tt.claims {
+				mockKeyRepo.On("Claim", matchKey).Return(claimed, nil).Once()
+			}
+			if tt.existing != nil {
+				mockKeyRepo.On("GetByKey", uint(1), tt.key).Return(tt.existing, nil)
+			} else if tt.lookupError != nil {
+				mockKeyRepo.On("GetByKey", uint(1), tt.key).Return(nil, tt.lookupError)
+			}
+			mockKeyRepo.On("Release", uint(1), tt.key).Return(nil).Maybe()
+
+			saved, err := idempotencyService.Begin(1, tt.key, "abc")
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, saved)
+			} else {
+				assert.NoError(t, err)
+				if tt.expectReplay {
+					assert.Equal(t, completed, saved)
+				} else {
+					assert.Nil(t, saved)
+				}
+			}
+			mockKeyRepo.AssertExpectations(t)
+			if tt.expectRelease {
+				mockKeyRepo.AssertCalled(t, "Release", uint(1), tt.key)
+			} else {
+				mockKeyRepo.AssertNotCalled(t, "Release", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package impl
+
+import (
+	"errors"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/payment"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+)
+
+// paymentService реализует интерфейс PaymentService
+type paymentService struct {
+	orderRepo   repository.OrderRepository
+	paymentRepo repository.PaymentRepository
+	uow         repository.UnitOfWork
+	gateway     payment.Gateway
+}
+
+// NewPaymentService создает новый экземпляр PaymentService
+func NewPaymentService(orderRepo repository.OrderRepository, paymentRepo repository.PaymentRepository, uow repository.UnitOfWork, gateway payment.Gateway) service.PaymentService {
+	return &paymentService{
+		orderRepo:   orderRepo,
+		paymentRepo: paymentRepo,
+		uow:         uow,
+		gateway:     gateway,
+	}
+}
+
+// PayOrder оплачивает заказ покупателя: авторизует и сразу списывает сумму заказа
+// Каждая попытка сохраняется как отдельный платеж. При успехе заказ переходит в статус paid;
+// если заказ перестал ожидать оплату, пока шлюз обрабатывал платеж, списанная сумма возвращается
+func (s *paymentService) PayOrder(userID uint, orderID uint, source string) (*domain.Payment, error) {
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, wrapNotFound(err, "order")
+	}
+	if order.UserID != userID {
+		return nil, service.ErrForbidden
+	}
+	if order.Status != domain.OrderStatusPending {
+		return nil, service.ErrOrderNotPayable
+	}
+
+	attempt := &domain.Payment{
+		OrderID:  order.ID,
+		Provider: s.gateway.Name(),
+		Status:   domain.PaymentStatusPending,
+		Amount:   order.Total,
+	}
+	if err := s.paymentRepo.Create(attempt); err != nil {
+		return nil, err
+	}
+
+	authorizationID, err := s.gateway.Authorize(payment.AuthorizeRequest{
+		OrderID: order.ID,
+		Amount:  order.Total,
+		Source:  source,
+	})
+	if err != nil {
+		return nil, s.fail(attempt, domain.PaymentStatusFailed, err)
+	}
+	attempt.Status = domain.PaymentStatusAuthorized
+	attempt.AuthorizationID = authorizationID
+	if err := s.paymentRepo.Update(attempt); err != nil {
+		return nil, err
+	}
+
+	transactionID, err := s.gateway.Capture(authorizationID, order.Total)
+	if err != nil {
+		// Не оставляем сумму заблокированной на счете покупателя
+		if voidErr := s.gateway.Void(authorizationID); voidErr != nil {
+			return nil, s.fail(attempt, domain.PaymentStatusFailed, errors.Join(err, voidErr))
+		}
+		return nil, s.fail(attempt, domain.PaymentStatusVoided, err)
+	}
+	attempt.Status = domain.PaymentStatusCaptured
+	attempt.TransactionID = transactionID
+
+	err = s.uow.Do(func(repos repository.Repositories) error {
+		current, err := repos.Orders.GetByIDForUpdate(order.ID)
+		if err != nil {
+			return wrapNotFound(err, "order")
+		}
+		if current.Status != domain.OrderStatusPending {
+			return service.ErrOrderNotPayable
+		}
+
+		if err := repos.Payments.Update(attempt); err != nil {
+			return err
+		}
+		if err := repos.Orders.UpdateStatus(order.ID, domain.OrderStatusPaid); err != nil {
+			return err
+		}
+		return recordStatusChange(repos.Orders, order.ID, current.Status, domain.OrderStatusPaid, userID)
+	})
+	if err != nil {
+		return nil, s.refundCaptured(attempt, err)
+	}
+	return attempt, nil
+}
+
+// refundCaptured возвращает списанную сумму, если заказ не удалось перевести в paid
+// Если возврат не прошел, платеж остается списанным, чтобы его можно было вернуть вручную
+func (s *paymentService) refundCaptured(attempt *domain.Payment, cause error) error {
+	if _, refundErr := s.gateway.Refund(attempt.TransactionID, attempt.Amount); refundErr != nil {
+		attempt.FailureReason = errors.Join(cause, refundErr).Error()
+		if err := s.paymentRepo.Update(attempt); err != nil {
+			return err
+		}
+		return cause
+	}
+
+	attempt.Status = domain.PaymentStatusRefunded
+	attempt.FailureReason = cause.Error()
+	if err := s.paymentRepo.Update(attempt); err != nil {
+		return err
+	}
+	return cause
+}
+
+// fail сохраняет неудачную попытку оплаты и переводит ошибку шлюза в доменную
+func (s *paymentService) fail(attempt *domain.Payment, status domain.PaymentStatus, cause error) error {
+	attempt.Status = status
+	attempt.FailureReason = cause.Error()
+	if err := s.paymentRepo.Update(attempt); err != nil {
+		return err
+	}
+	return gatewayError(cause)
+}
+
+// gatewayError переводит ошибку платежного шлюза в доменную
+func gatewayError(err error) error {
+	switch {
+	case errors.Is(err, payment.ErrDeclined):
+		return domain.NewPaymentDeclinedError(err)
+	case errors.Is(err, payment.ErrTimeout):
+		return domain.NewPaymentTimeoutError(err)
+	}
+	return err
+}
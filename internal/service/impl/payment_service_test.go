@@ -0,0 +1,130 @@
+package impl
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/payment"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPaymentRepository - мок репозитория платежей
+type MockPaymentRepository struct {
+	mock.Mock
+}
+
+func (m *MockPaymentRepository) Create(payment *domain.Payment) error {
+	args := m.Called(payment)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) Update(payment *domain.Payment) error {
+	args := m.Called(payment)
+	return args.Error(0)
+}
+
+func (m *MockPaymentRepository) GetByOrderID(orderID uint) ([]domain.Payment, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Payment), args.Error(1)
+}
+
+func TestPayOrder(t *testing.T) {
+	tests := []struct {
+		name           string
+		orderUserID    uint
+		orderStatus    domain.OrderStatus
+		lockedStatus   domain.OrderStatus
+		source         string
+		failures       map[payment.Operation]payment.FailureMode
+		expectedStatus domain.PaymentStatus
+		expectedError  error
+	}{
+		{name: "Успешная оплата", orderUserID: 1, orderStatus: domain.OrderStatusPending, source: "tok_visa", expectedStatus: domain.PaymentStatusCaptured},
+		{name: "Платеж отклонен", orderUserID: 1, orderStatus: domain.OrderStatusPending, source: payment.SourceDecline, expectedStatus: domain.PaymentStatusFailed, expectedError: domain.ErrPaymentDeclined},
+		{name: "Шлюз не ответил", orderUserID: 1, orderStatus: domain.OrderStatusPending, source: payment.SourceTimeout, expectedStatus: domain.PaymentStatusFailed, expectedError: domain.ErrPaymentTimeout},
+		{
+			name:           "Списание отклонено, авторизация отменена",
+			orderUserID:    1,
+			orderStatus:    domain.OrderStatusPending,
+			source:         "tok_visa",
+			failures:       map[payment.Operation]payment.FailureMode{payment.OperationCapture: payment.FailureDecline},
+			expectedStatus: domain.PaymentStatusVoided,
+			expectedError:  domain.ErrPaymentDeclined,
+		},
+		{
+			name:           "Заказ оплачен параллельно, деньги возвращены",
+			orderUserID:    1,
+			orderStatus:    domain.OrderStatusPending,
+			lockedStatus:   domain.OrderStatusPaid,
+			source:         "tok_visa",
+			expectedStatus: domain.PaymentStatusRefunded,
+			expectedError:  domain.ErrConflict,
+		},
+		{name: "Заказ уже оплачен", orderUserID: 1, orderStatus: domain.OrderStatusPaid, source: "tok_visa", expectedError: service.ErrOrderNotPayable},
+		{name: "Чужой заказ", orderUserID: 2, orderStatus: domain.OrderStatusPending, source: "tok_visa", expectedError: service.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOrderRepo := new(MockOrderRepository)
+			mockPaymentRepo := new(MockPaymentRepository)
+			txOrders := new(MockOrderRepository)
+			uow := &fakeUnitOfWork{repos: repository.Repositories{Orders: txOrders, Payments: mockPaymentRepo}}
+			paymentService := NewPaymentService(mockOrderRepo, mockPaymentRepo, uow, payment.NewFakeGateway(tt.failures))
+
+			mockOrderRepo.On("GetByID", uint(5)).Return(&domain.Order{
+				ID:     5,
+				UserID: tt.orderUserID,
+				Status: tt.orderStatus,
+				Total:  domain.NewMoney(150000, "RUB"),
+			}, nil)
+
+			var attempt *domain.Payment
+			mockPaymentRepo.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+				attempt = args.Get(0).(*domain.Payment)
+			}).Return(nil).Maybe()
+			mockPaymentRepo.On("Update", mock.Anything).Return(nil).Maybe()
+
+			lockedStatus := tt.lockedStatus
+			if lockedStatus == "" {
+				lockedStatus = tt.orderStatus
+			}
+			txOrders.On("GetByIDForUpdate", uint(5)).Return(&domain.Order{ID: 5, Status: lockedStatus}, nil).Maybe()
+			txOrders.On("UpdateStatus", uint(5), domain.OrderStatusPaid).Return(nil).Maybe()
+			txOrders.On("AddStatusHistory", mock.MatchedBy(func(h *domain.OrderStatusHistory) bool {
+				return h.OrderID == 5 && h.ToStatus == domain.OrderStatusPaid && *h.ChangedBy == 1
+			})).Return(nil).Maybe()
+
+			result, err := paymentService.PayOrder(1, 5, tt.source)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+				txOrders.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, domain.NewMoney(150000, "RUB"), result.Amount)
+				assert.NotEmpty(t, result.TransactionID)
+				txOrders.AssertExpectations(t)
+				assert.True(t, uow.committed)
+			}
+
+			if tt.expectedStatus == "" {
+				mockPaymentRepo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+			if assert.NotNil(t, attempt) {
+				assert.Equal(t, tt.expectedStatus, attempt.Status)
+				assert.Equal(t, "fake", attempt.Provider)
+				if tt.expectedError != nil {
+					assert.NotEmpty(t, attempt.FailureReason)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package impl
+
+import (
+	"errors"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// promotionService реализует интерфейс PromotionService
+type promotionService struct {
+	promotionRepo repository.PromotionRepository
+}
+
+// NewPromotionService создает новый экземпляр PromotionService
+func NewPromotionService(promotionRepo repository.PromotionRepository) service.PromotionService {
+	return &promotionService{
+		promotionRepo: promotionRepo,
+	}
+}
+
+// CreatePromotion создает новую акцию
+func (s *promotionService) CreatePromotion(promotion *domain.Promotion) error {
+	promotion.Code = normalizeCouponCode(promotion.Code)
+	promotion.UsedCount = 0
+	for _, money := range []*domain.Money{&promotion.AmountOff, &promotion.MinSubtotal} {
+		if money.Currency == "" {
+			money.Currency = domain.DefaultCurrency
+		}
+	}
+	if err := promotion.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.promotionRepo.GetByCode(promotion.Code); err == nil {
+		return domain.NewConflictError("promotion code already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return s.promotionRepo.Create(promotion)
+}
+
+// GetAllPromotions возвращает все акции
+func (s *promotionService) GetAllPromotions() ([]domain.Promotion, error) {
+	return s.promotionRepo.GetAll()
+}
+
+// normalizeCouponCode приводит код купона к каноническому виду
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// resolveCoupon возвращает акцию по коду купона, если пользователь может ее применить:
+// акция действует в момент now и лимиты использований не исчерпаны.
+// Для гостя (userID 0) лимит на пользователя не проверяется
+func resolveCoupon(promotions repository.PromotionRepository, code string, userID uint, now time.Time) (*domain.Promotion, error) {
+	promotion, err := promotions.GetByCode(normalizeCouponCode(code))
+	if err != nil {
+		return nil, wrapNotFound(err, "coupon")
+	}
+
+	if !promotion.IsActiveAt(now) {
+		return nil, service.ErrCouponInactive
+	}
+	if promotion.UsageLimit > 0 && promotion.UsedCount >= promotion.UsageLimit {
+		return nil, service.ErrCouponLimitReached
+	}
+	if promotion.UsageLimitPerUser > 0 && userID != 0 {
+		used, err := promotions.CountRedemptions(promotion.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(promotion.UsageLimitPerUser) {
+			return nil, service.ErrCouponLimitReached
+		}
+	}
+	return promotion, nil
+}
+
+// cartPromotion возвращает акцию по купону корзины для расчета итогов
+// Купон, который больше нельзя применить, не учитывается, как и в отображении корзины
+func cartPromotion(promotions repository.PromotionRepository, cart *domain.Cart, now time.Time) (*domain.Promotion, error) {
+	if cart.CouponCode == "" {
+		return nil, nil
+	}
+
+	promotion, err := resolveCoupon(promotions, cart.CouponCode, cart.Owner().UserID, now)
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		return nil, nil
+	}
+	return promotion, err
+}
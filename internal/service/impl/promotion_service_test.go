@@ -0,0 +1,130 @@
+package impl
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockPromotionRepository - мок репозитория акций
+type MockPromotionRepository struct {
+	mock.Mock
+}
+
+func (m *MockPromotionRepository) Create(promotion *domain.Promotion) error {
+	args := m.Called(promotion)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) GetByCode(code string) (*domain.Promotion, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) GetAll() ([]domain.Promotion, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) CountRedemptions(promotionID uint, userID uint) (int64, error) {
+	args := m.Called(promotionID, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPromotionRepository) Redeem(redemption *domain.PromotionRedemption) (bool, error) {
+	args := m.Called(redemption)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestApplyCoupon(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name             string
+		setupMocks       func(promotions *MockPromotionRepository)
+		expectedError    error
+		expectedDiscount domain.Money
+	}{
+		{
+			name: "Процентная скидка",
+			setupMocks: func(promotions *MockPromotionRepository) {
+				promotions.On("GetByCode", "SALE10").Return(&domain.Promotion{ID: 1, Code: "SALE10", Type: domain.PromotionPercentage, PercentOff: 10}, nil)
+			},
+			expectedDiscount: domain.NewMoney(400, "RUB"),
+		},
+		{
+			name: "Неизвестный купон",
+			setupMocks: func(promotions *MockPromotionRepository) {
+				promotions.On("GetByCode", "SALE10").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedError: domain.ErrNotFound,
+		},
+		{
+			name: "Срок действия истек",
+			setupMocks: func(promotions *MockPromotionRepository) {
+				promotions.On("GetByCode", "SALE10").Return(&domain.Promotion{ID: 1, Code: "SALE10", Type: domain.PromotionPercentage, PercentOff: 10, EndsAt: &past}, nil)
+			},
+			expectedError: service.ErrCouponInactive,
+		},
+		{
+			name: "Общий лимит исчерпан",
+			setupMocks: func(promotions *MockPromotionRepository) {
+				promotions.On("GetByCode", "SALE10").Return(&domain.Promotion{ID: 1, Code: "SALE10", Type: domain.PromotionPercentage, PercentOff: 10, UsageLimit: 5, UsedCount: 5}, nil)
+			},
+			expectedError: service.ErrCouponLimitReached,
+		},
+		{
+			name: "Лимит на пользователя исчерпан",
+			setupMocks: func(promotions *MockPromotionRepository) {
+				promotions.On("GetByCode", "SALE10").Return(&domain.Promotion{ID: 1, Code: "SALE10", Type: domain.PromotionPercentage, PercentOff: 10, UsageLimitPerUser: 1}, nil)
+				promotions.On("CountRedemptions", uint(1), uint(1)).Return(int64(1), nil)
+			},
+			expectedError: service.ErrCouponLimitReached,
+		},
+		{
+			name: "Сумма корзины меньше минимальной",
+			setupMocks: func(promotions *MockPromotionRepository) {
+				promotions.On("GetByCode", "SALE10").Return(&domain.Promotion{ID: 1, Code: "SALE10", Type: domain.PromotionPercentage, PercentOff: 10, MinSubtotal: domain.NewMoney(100000, "RUB")}, nil)
+			},
+			expectedError: service.ErrCouponNotApplicable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			mockPromotionRepo := new(MockPromotionRepository)
+			cartService := NewCartService(mockCartRepo, new(MockCartItemRepository), new(MockProductRepository), mockPromotionRepo, &fakeUnitOfWork{}, newTestEngine())
+
+			mockCartRepo.On("GetOrCreate", customer).Return(&domain.Cart{ID: 1, UserID: uintPtr(1), Items: []domain.CartItem{
+				{ID: 1, ProductID: 10, Quantity: 2, Product: domain.Product{ID: 10, Price: domain.NewMoney(2000, "RUB")}},
+			}}, nil)
+			tt.setupMocks(mockPromotionRepo)
+			if tt.expectedError == nil {
+				mockCartRepo.On("SetCouponCode", uint(1), "SALE10").Return(nil)
+			}
+
+			cart, err := cartService.ApplyCoupon(customer, " sale10 ")
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				mockCartRepo.AssertNotCalled(t, "SetCouponCode", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "SALE10", cart.CouponCode)
+			assert.Equal(t, tt.expectedDiscount, cart.Summary.Discount)
+			mockCartRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package impl
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/payment"
+	"shopping-cart/internal/repository"
+)
+
+// capturedPayment возвращает списанный платеж заказа или nil, если заказ не оплачивался через шлюз
+func capturedPayment(payments repository.PaymentRepository, orderID uint) (*domain.Payment, error) {
+	attempts, err := payments.GetByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range attempts {
+		if attempts[i].Status == domain.PaymentStatusCaptured {
+			return &attempts[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// pendingRefund отмечает, что refund возвращается через шлюз по списанному платежу captured
+// Сохраненный возврат проводится через шлюз функцией settleRefund после фиксации транзакции
+func pendingRefund(captured *domain.Payment, refund *domain.Refund) {
+	refund.PaymentID = &captured.ID
+	refund.Status = domain.RefundStatusPending
+}
+
+// settleRefund возвращает деньги по сохраненному возврату через шлюз и записывает результат
+// Вызывается после фиксации транзакции: откат транзакции не может отменить уже выполненный возврат в шлюзе.
+// Если full, платеж помечается возвращенным. Отказ шлюза сохраняется в возврате со статусом failed
+func settleRefund(uow repository.UnitOfWork, gateway payment.Gateway, captured *domain.Payment, refund *domain.Refund, full bool) error {
+	transactionID, err := gateway.Refund(captured.TransactionID, refund.Amount)
+	if err != nil {
+		refund.Status = domain.RefundStatusFailed
+		refund.FailureReason = err.Error()
+		if updateErr := uow.Do(func(repos repository.Repositories) error {
+			return repos.Returns.UpdateRefund(refund)
+		}); updateErr != nil {
+			return updateErr
+		}
+		return gatewayError(err)
+	}
+
+	refund.Status = domain.RefundStatusSucceeded
+	refund.TransactionID = transactionID
+	return uow.Do(func(repos repository.Repositories) error {
+		if full {
+			captured.Status = domain.PaymentStatusRefunded
+			if err := repos.Payments.Update(captured); err != nil {
+				return err
+			}
+		}
+		return repos.Returns.UpdateRefund(refund)
+	})
+}
@@ -0,0 +1,361 @@
+package impl
+
+import (
+	"fmt"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/payment"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+	"strings"
+	"time"
+)
+
+// returnService реализует интерфейс ReturnService
+type returnService struct {
+	orderRepo  repository.OrderRepository
+	returnRepo repository.ReturnRepository
+	uow        repository.UnitOfWork
+	gateway    payment.Gateway
+}
+
+// NewReturnService создает новый экземпляр ReturnService
+func NewReturnService(orderRepo repository.OrderRepository, returnRepo repository.ReturnRepository, uow repository.UnitOfWork, gateway payment.Gateway) service.ReturnService {
+	return &returnService{
+		orderRepo:  orderRepo,
+		returnRepo: returnRepo,
+		uow:        uow,
+		gateway:    gateway,
+	}
+}
+
+// RequestReturn создает заявку покупателя на возврат позиций доставленного заказа
+// Вернуть можно не больше единиц, чем куплено, за вычетом уже одобренных и ожидающих решения заявок
+func (s *returnService) RequestReturn(userID uint, orderID uint, reason string, lines []service.ReturnLine) (*domain.OrderReturn, error) {
+	if len(lines) == 0 {
+		return nil, domain.NewValidationError("at least one item is required")
+	}
+
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, wrapNotFound(err, "order")
+	}
+	if order.UserID != userID {
+		return nil, service.ErrForbidden
+	}
+	if !order.Status.IsReturnable() {
+		return nil, service.ErrOrderNotReturnable
+	}
+
+	existing, err := s.returnRepo.GetByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	available := returnableQuantities(order.Items, existing)
+
+	orderReturn := &domain.OrderReturn{
+		OrderID: orderID,
+		UserID:  userID,
+		Status:  domain.ReturnStatusRequested,
+		Reason:  strings.TrimSpace(reason),
+		Amount:  domain.Zero(order.Total.Currency),
+	}
+	for _, line := range lines {
+		item := findOrderItem(order.Items, line.OrderItemID)
+		if item == nil {
+			return nil, domain.NewValidationError(fmt.Sprintf("order item %d does not belong to the order", line.OrderItemID))
+		}
+		if line.Quantity <= 0 {
+			return nil, domain.NewValidationError("quantity must be positive")
+		}
+		if line.Quantity > available[item.ID] {
+			return nil, domain.NewValidationError(fmt.Sprintf("only %d units of order item %d can be returned", available[item.ID], item.ID))
+		}
+		available[item.ID] -= line.Quantity
+
+		amount := returnLineAmount(order, item, line.Quantity)
+		orderReturn.Items = append(orderReturn.Items, domain.ReturnItem{
+			OrderItemID: item.ID,
+			Quantity:    line.Quantity,
+			Amount:      amount,
+		})
+		if orderReturn.Amount, err = orderReturn.Amount.Add(amount); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.returnRepo.Create(orderReturn); err != nil {
+		return nil, err
+	}
+	return orderReturn, nil
+}
+
+// GetOrderReturns возвращает заявки на возврат по заказу
+// Права доступа те же, что и у просмотра заказа
+func (s *returnService) GetOrderReturns(userID uint, role domain.Role, orderID uint) ([]domain.OrderReturn, error) {
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, wrapNotFound(err, "order")
+	}
+	if order.UserID != userID && !role.Can(domain.PermissionReadAllOrders) {
+		return nil, service.ErrForbidden
+	}
+	return s.returnRepo.GetByOrderID(orderID)
+}
+
+// ApproveReturn одобряет заявку: возвращает товары на склад, деньги покупателю
+// и переводит заказ в partially_refunded или refunded, если возвращено все
+// Возврат денег сохраняется в транзакции как pending и проводится через шлюз после ее фиксации;
+// отказ шлюза не отменяет одобрение, а сохраняется в возврате со статусом failed.
+// При полном возврате покупателю возвращается весь остаток суммы заказа, включая доставку
+func (s *returnService) ApproveReturn(actorID uint, returnID uint, note string) (*domain.OrderReturn, error) {
+	var approved *domain.OrderReturn
+	var refund *domain.Refund
+	var captured *domain.Payment
+	var fullyReturned bool
+	err := s.uow.Do(func(repos repository.Repositories) error {
+		orderReturn, order, err := lockReturn(repos, returnID)
+		if err != nil {
+			return err
+		}
+		if !order.Status.IsReturnable() {
+			return service.ErrOrderNotReturnable
+		}
+
+		returns, err := repos.Returns.GetByOrderID(order.ID)
+		if err != nil {
+			return err
+		}
+		// RequestReturn проверяет количество без блокировки, поэтому параллельные заявки
+		// могли занять одни и те же единицы: одобрить можно только еще не возвращенные
+		left := unreturnedQuantities(order.Items, returns, orderReturn)
+		approving := make(map[uint]int, len(orderReturn.Items))
+		for _, line := range orderReturn.Items {
+			approving[line.OrderItemID] += line.Quantity
+			if approving[line.OrderItemID] > left[line.OrderItemID] {
+				return domain.NewConflictError(fmt.Sprintf("only %d units of order item %d can be returned", left[line.OrderItemID], line.OrderItemID))
+			}
+		}
+		fullyReturned = isFullyReturned(left, orderReturn)
+
+		refunds, err := repos.Returns.GetRefundsByOrderID(order.ID)
+		if err != nil {
+			return err
+		}
+		remaining := order.Total
+		for _, refund := range refunds {
+			if remaining, err = remaining.Sub(refund.Amount); err != nil {
+				return err
+			}
+		}
+		amount := orderReturn.Amount
+		if fullyReturned || amount.Amount > remaining.Amount {
+			amount = remaining
+		}
+		if amount.IsNegative() {
+			amount = domain.Zero(order.Total.Currency)
+		}
+
+		stock := make([]domain.OrderItem, 0, len(orderReturn.Items))
+		for _, line := range orderReturn.Items {
+			if item := findOrderItem(order.Items, line.OrderItemID); item != nil {
+				stock = append(stock, domain.OrderItem{ProductID: item.ProductID, Quantity: line.Quantity})
+			}
+		}
+		if err := releaseStock(repos.Products, stock); err != nil {
+			return err
+		}
+
+		next := domain.OrderStatusPartiallyRefunded
+		if fullyReturned {
+			next = domain.OrderStatusRefunded
+		}
+		if order.Status != next {
+			if !order.Status.CanRefundTo(next) {
+				return domain.NewConflictError(fmt.Sprintf("cannot change order status from %s to %s", order.Status, next))
+			}
+			if err := repos.Orders.UpdateStatus(order.ID, next); err != nil {
+				return err
+			}
+			if err := recordStatusChange(repos.Orders, order.ID, order.Status, next, actorID); err != nil {
+				return err
+			}
+		}
+
+		review(orderReturn, domain.ReturnStatusApproved, actorID, note)
+		orderReturn.Amount = amount
+		if err := repos.Returns.Update(orderReturn); err != nil {
+			return err
+		}
+
+		refund = &domain.Refund{
+			OrderID:   order.ID,
+			ReturnID:  &orderReturn.ID,
+			Status:    domain.RefundStatusSucceeded,
+			Amount:    amount,
+			CreatedBy: &actorID,
+		}
+		if captured, err = refundPayment(repos.Payments, refund); err != nil {
+			return err
+		}
+		if captured != nil {
+			pendingRefund(captured, refund)
+		}
+		if err := repos.Returns.CreateRefund(refund); err != nil {
+			return err
+		}
+
+		approved = orderReturn
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if captured != nil {
+		if err := settleRefund(s.uow, s.gateway, captured, refund, fullyReturned); err != nil {
+			return nil, err
+		}
+	}
+	return approved, nil
+}
+
+// RejectReturn отклоняет заявку на возврат с указанием причины
+func (s *returnService) RejectReturn(actorID uint, returnID uint, note string) (*domain.OrderReturn, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, domain.NewValidationError("rejection note is required")
+	}
+
+	var rejected *domain.OrderReturn
+	err := s.uow.Do(func(repos repository.Repositories) error {
+		orderReturn, _, err := lockReturn(repos, returnID)
+		if err != nil {
+			return err
+		}
+
+		review(orderReturn, domain.ReturnStatusRejected, actorID, note)
+		if err := repos.Returns.Update(orderReturn); err != nil {
+			return err
+		}
+		rejected = orderReturn
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rejected, nil
+}
+
+// refundPayment возвращает списанный платеж, по которому refund нужно провести через шлюз,
+// или nil, если возвращать через шлюз нечего. Заказ, оплаченный вне API (например, по вебхуку
+// провайдера), возвращается без обращения к шлюзу
+func refundPayment(payments repository.PaymentRepository, refund *domain.Refund) (*domain.Payment, error) {
+	if refund.Amount.Amount <= 0 {
+		return nil, nil
+	}
+	return capturedPayment(payments, refund.OrderID)
+}
+
+// lockReturn загружает открытую заявку на возврат и блокирует ее заказ
+// Решения по заявкам одного заказа принимаются последовательно
+func lockReturn(repos repository.Repositories, returnID uint) (*domain.OrderReturn, *domain.Order, error) {
+	orderReturn, err := repos.Returns.GetByID(returnID)
+	if err != nil {
+		return nil, nil, wrapNotFound(err, "return")
+	}
+	order, err := repos.Orders.GetByIDForUpdate(orderReturn.OrderID)
+	if err != nil {
+		return nil, nil, wrapNotFound(err, "order")
+	}
+
+	// Перечитываем заявку под блокировкой: решение могло быть принято параллельно
+	if orderReturn, err = repos.Returns.GetByID(returnID); err != nil {
+		return nil, nil, wrapNotFound(err, "return")
+	}
+	if !orderReturn.IsOpen() {
+		return nil, nil, service.ErrReturnClosed
+	}
+	return orderReturn, order, nil
+}
+
+// review записывает решение по заявке
+func review(orderReturn *domain.OrderReturn, status domain.ReturnStatus, actorID uint, note string) {
+	now := time.Now()
+	orderReturn.Status = status
+	orderReturn.ReviewedBy = &actorID
+	orderReturn.ReviewedAt = &now
+	orderReturn.ReviewNote = strings.TrimSpace(note)
+}
+
+// returnableQuantities возвращает количество единиц каждой позиции, доступное для возврата
+// Единицы в отклоненных заявках снова доступны
+func returnableQuantities(items []domain.OrderItem, returns []domain.OrderReturn) map[uint]int {
+	available := make(map[uint]int, len(items))
+	for _, item := range items {
+		available[item.ID] = item.Quantity
+	}
+	for _, orderReturn := range returns {
+		if orderReturn.Status == domain.ReturnStatusRejected {
+			continue
+		}
+		for _, line := range orderReturn.Items {
+			available[line.OrderItemID] -= line.Quantity
+		}
+	}
+	return available
+}
+
+// unreturnedQuantities возвращает количество единиц каждой позиции, не возвращенных
+// одобренными заявками, кроме заявки current
+func unreturnedQuantities(items []domain.OrderItem, returns []domain.OrderReturn, current *domain.OrderReturn) map[uint]int {
+	left := make(map[uint]int, len(items))
+	for _, item := range items {
+		left[item.ID] = item.Quantity
+	}
+	for _, orderReturn := range returns {
+		if orderReturn.Status != domain.ReturnStatusApproved || orderReturn.ID == current.ID {
+			continue
+		}
+		for _, line := range orderReturn.Items {
+			left[line.OrderItemID] -= line.Quantity
+		}
+	}
+	return left
+}
+
+// isFullyReturned проверяет, будут ли возвращены все единицы заказа после одобрения заявки current
+// left - невозвращенные единицы, посчитанные unreturnedQuantities
+func isFullyReturned(left map[uint]int, current *domain.OrderReturn) bool {
+	returned := make(map[uint]int, len(current.Items))
+	for _, line := range current.Items {
+		returned[line.OrderItemID] += line.Quantity
+	}
+	for id, quantity := range left {
+		if quantity > returned[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// returnLineAmount рассчитывает сумму к возврату за quantity единиц позиции по цене из заказа
+// за вычетом их доли скидки на товары. Если налог начислялся сверх цены, возвращается и его доля
+func returnLineAmount(order *domain.Order, item *domain.OrderItem, quantity int) domain.Money {
+	amount := item.Price.Mul(quantity)
+	if item.Quantity > 0 {
+		amount.Amount -= item.Discount.Amount * int64(quantity) / int64(item.Quantity)
+		if !order.TaxInclusive {
+			amount.Amount += item.Tax.Amount * int64(quantity) / int64(item.Quantity)
+		}
+	}
+	return amount
+}
+
+// findOrderItem ищет позицию заказа по идентификатору
+func findOrderItem(items []domain.OrderItem, id uint) *domain.OrderItem {
+	for i := range items {
+		if items[i].ID == id {
+			return &items[i]
+		}
+	}
+	return nil
+}
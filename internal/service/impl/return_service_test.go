@@ -0,0 +1,407 @@
+package impl
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/payment"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockReturnRepository - мок репозитория возвратов
+type MockReturnRepository struct {
+	mock.Mock
+}
+
+func (m *MockReturnRepository) Create(orderReturn *domain.OrderReturn) error {
+	args := m.Called(orderReturn)
+	return args.Error(0)
+}
+
+func (m *MockReturnRepository) GetByID(id uint) (*domain.OrderReturn, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OrderReturn), args.Error(1)
+}
+
+func (m *MockReturnRepository) GetByOrderID(orderID uint) ([]domain.OrderReturn, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.OrderReturn), args.Error(1)
+}
+
+func (m *MockReturnRepository) Update(orderReturn *domain.OrderReturn) error {
+	args := m.Called(orderReturn)
+	return args.Error(0)
+}
+
+func (m *MockReturnRepository) CreateRefund(refund *domain.Refund) error {
+	args := m.Called(refund)
+	return args.Error(0)
+}
+
+func (m *MockReturnRepository) UpdateRefund(refund *domain.Refund) error {
+	args := m.Called(refund)
+	return args.Error(0)
+}
+
+func (m *MockReturnRepository) GetRefundsByOrderID(orderID uint) ([]domain.Refund, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Refund), args.Error(1)
+}
+
+// returnTestOrder - доставленный заказ из двух позиций: 2 x 100.00 и 1 x 50.00 плюс доставка 30.00
+func returnTestOrder(status domain.OrderStatus) *domain.Order {
+	return &domain.Order{
+		ID:     7,
+		UserID: 1,
+		Status: status,
+		Items: []domain.OrderItem{
+			{ID: 71, ProductID: 10, Quantity: 2, Price: domain.NewMoney(10000, "RUB")},
+			{ID: 72, ProductID: 11, Quantity: 1, Price: domain.NewMoney(5000, "RUB")},
+		},
+		TaxInclusive: true,
+		Shipping:     domain.NewMoney(3000, "RUB"),
+		Total:        domain.NewMoney(28000, "RUB"),
+	}
+}
+
+func TestRequestReturn(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint
+		status         domain.OrderStatus
+		existing       []domain.OrderReturn
+		discounted     bool
+		lines          []service.ReturnLine
+		expectedAmount int64
+		expectedError  error
+	}{
+		{
+			name:           "Возврат части позиции",
+			userID:         1,
+			status:         domain.OrderStatusDelivered,
+			lines:          []service.ReturnLine{{OrderItemID: 71, Quantity: 1}, {OrderItemID: 72, Quantity: 1}},
+			expectedAmount: 15000,
+		},
+		{
+			// Купон на 50% распределен по позициям: возвращается цена за вычетом доли скидки
+			name:           "Возврат из заказа со скидкой",
+			userID:         1,
+			status:         domain.OrderStatusDelivered,
+			discounted:     true,
+			lines:          []service.ReturnLine{{OrderItemID: 71, Quantity: 1}},
+			expectedAmount: 5000,
+		},
+		{
+			name:   "Отклоненная заявка не занимает единицы",
+			userID: 1,
+			status: domain.OrderStatusPartiallyRefunded,
+			existing: []domain.OrderReturn{
+				{Status: domain.ReturnStatusApproved, Items: []domain.ReturnItem{{OrderItemID: 71, Quantity: 1}}},
+				{Status: domain.ReturnStatusRejected, Items: []domain.ReturnItem{{OrderItemID: 71, Quantity: 1}}},
+			},
+			lines:          []service.ReturnLine{{OrderItemID: 71, Quantity: 1}},
+			expectedAmount: 10000,
+		},
+		{
+			name:   "Больше единиц, чем осталось",
+			userID: 1,
+			status: domain.OrderStatusDelivered,
+			existing: []domain.OrderReturn{
+				{Status: domain.ReturnStatusRequested, Items: []domain.ReturnItem{{OrderItemID: 71, Quantity: 1}}},
+			},
+			lines:         []service.ReturnLine{{OrderItemID: 71, Quantity: 2}},
+			expectedError: domain.ErrValidation,
+		},
+		{
+			name:          "Одна позиция дважды сверх купленного",
+			userID:        1,
+			status:        domain.OrderStatusDelivered,
+			lines:         []service.ReturnLine{{OrderItemID: 72, Quantity: 1}, {OrderItemID: 72, Quantity: 1}},
+			expectedError: domain.ErrValidation,
+		},
+		{
+			name:          "Позиция другого заказа",
+			userID:        1,
+			status:        domain.OrderStatusDelivered,
+			lines:         []service.ReturnLine{{OrderItemID: 99, Quantity: 1}},
+			expectedError: domain.ErrValidation,
+		},
+		{
+			name:          "Заказ еще не доставлен",
+			userID:        1,
+			status:        domain.OrderStatusShipped,
+			lines:         []service.ReturnLine{{OrderItemID: 71, Quantity: 1}},
+			expectedError: service.ErrOrderNotReturnable,
+		},
+		{
+			name:          "Чужой заказ",
+			userID:        2,
+			status:        domain.OrderStatusDelivered,
+			lines:         []service.ReturnLine{{OrderItemID: 71, Quantity: 1}},
+			expectedError: service.ErrForbidden,
+		},
+		{
+			name:          "Без позиций",
+			userID:        1,
+			status:        domain.OrderStatusDelivered,
+			expectedError: domain.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOrderRepo := new(MockOrderRepository)
+			mockReturnRepo := new(MockReturnRepository)
+			returnService := NewReturnService(mockOrderRepo, mockReturnRepo, &fakeUnitOfWork{}, payment.NewFakeGateway(nil))
+
+			order := returnTestOrder(tt.status)
+			if tt.discounted {
+				order.Items[0].Discount = domain.NewMoney(10000, "RUB")
+				order.Items[1].Discount = domain.NewMoney(2500, "RUB")
+				order.Discount = domain.NewMoney(12500, "RUB")
+				order.Total = domain.NewMoney(15500, "RUB")
+			}
+			mockOrderRepo.On("GetByID", uint(7)).Return(order, nil)
+			mockReturnRepo.On("GetByOrderID", uint(7)).Return(tt.existing, nil)
+			mockReturnRepo.On("Create", mock.Anything).Return(nil).Maybe()
+
+			orderReturn, err := returnService.RequestReturn(tt.userID, 7, " не подошел размер ", tt.lines)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				mockReturnRepo.AssertNotCalled(t, "Create", mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, domain.ReturnStatusRequested, orderReturn.Status)
+			assert.Equal(t, "не подошел размер", orderReturn.Reason)
+			assert.Equal(t, domain.NewMoney(tt.expectedAmount, "RUB"), orderReturn.Amount)
+			assert.Len(t, orderReturn.Items, len(tt.lines))
+		})
+	}
+}
+
+func TestApproveReturn(t *testing.T) {
+	tests := []struct {
+		name            string
+		orderStatus     domain.OrderStatus
+		returnStatus    domain.ReturnStatus
+		items           []domain.ReturnItem
+		amount          int64
+		previous        []domain.OrderReturn
+		refunds         []domain.Refund
+		paidThroughAPI  bool
+		refundFailure   payment.FailureMode
+		expectedStatus  domain.OrderStatus
+		expectedRefund  int64
+		expectedPayment domain.PaymentStatus
+		expectedError   error
+	}{
+		{
+			name:            "Частичный возврат",
+			orderStatus:     domain.OrderStatusDelivered,
+			returnStatus:    domain.ReturnStatusRequested,
+			items:           []domain.ReturnItem{{OrderItemID: 71, Quantity: 1}},
+			amount:          10000,
+			paidThroughAPI:  true,
+			expectedStatus:  domain.OrderStatusPartiallyRefunded,
+			expectedRefund:  10000,
+			expectedPayment: domain.PaymentStatusCaptured,
+		},
+		{
+			name:         "Полный возврат возвращает остаток вместе с доставкой",
+			orderStatus:  domain.OrderStatusPartiallyRefunded,
+			returnStatus: domain.ReturnStatusRequested,
+			items:        []domain.ReturnItem{{OrderItemID: 71, Quantity: 1}, {OrderItemID: 72, Quantity: 1}},
+			amount:       15000,
+			previous: []domain.OrderReturn{
+				{ID: 1, Status: domain.ReturnStatusApproved, Items: []domain.ReturnItem{{OrderItemID: 71, Quantity: 1}}},
+			},
+			refunds:         []domain.Refund{{Amount: domain.NewMoney(10000, "RUB")}},
+			paidThroughAPI:  true,
+			expectedStatus:  domain.OrderStatusRefunded,
+			expectedRefund:  18000,
+			expectedPayment: domain.PaymentStatusRefunded,
+		},
+		{
+			// Шлюз вызывается после фиксации: одобрение остается, возврат помечается failed
+			name:            "Шлюз отклонил возврат",
+			orderStatus:     domain.OrderStatusDelivered,
+			returnStatus:    domain.ReturnStatusRequested,
+			items:           []domain.ReturnItem{{OrderItemID: 71, Quantity: 1}},
+			amount:          10000,
+			paidThroughAPI:  true,
+			refundFailure:   payment.FailureDecline,
+			expectedStatus:  domain.OrderStatusPartiallyRefunded,
+			expectedRefund:  10000,
+			expectedPayment: domain.PaymentStatusCaptured,
+			expectedError:   domain.ErrPaymentDeclined,
+		},
+		{
+			name:           "Заказ оплачен вне API",
+			orderStatus:    domain.OrderStatusDelivered,
+			returnStatus:   domain.ReturnStatusRequested,
+			items:          []domain.ReturnItem{{OrderItemID: 72, Quantity: 1}},
+			amount:         5000,
+			expectedStatus: domain.OrderStatusPartiallyRefunded,
+			expectedRefund: 5000,
+		},
+		{
+			name:         "Единицы уже возвращены параллельной заявкой",
+			orderStatus:  domain.OrderStatusPartiallyRefunded,
+			returnStatus: domain.ReturnStatusRequested,
+			items:        []domain.ReturnItem{{OrderItemID: 72, Quantity: 1}},
+			amount:       5000,
+			previous: []domain.OrderReturn{
+				{ID: 1, Status: domain.ReturnStatusApproved, Items: []domain.ReturnItem{{OrderItemID: 72, Quantity: 1}}},
+			},
+			expectedError: domain.ErrConflict,
+		},
+		{
+			name:         "Ожидающая заявка на те же единицы не мешает одобрению",
+			orderStatus:  domain.OrderStatusDelivered,
+			returnStatus: domain.ReturnStatusRequested,
+			items:        []domain.ReturnItem{{OrderItemID: 72, Quantity: 1}},
+			amount:       5000,
+			previous: []domain.OrderReturn{
+				{ID: 1, Status: domain.ReturnStatusRequested, Items: []domain.ReturnItem{{OrderItemID: 72, Quantity: 1}}},
+			},
+			expectedStatus: domain.OrderStatusPartiallyRefunded,
+			expectedRefund: 5000,
+		},
+		{
+			name:          "Решение уже принято",
+			orderStatus:   domain.OrderStatusPartiallyRefunded,
+			returnStatus:  domain.ReturnStatusApproved,
+			items:         []domain.ReturnItem{{OrderItemID: 71, Quantity: 1}},
+			amount:        10000,
+			expectedError: service.ErrReturnClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := payment.NewFakeGateway(nil)
+			var payments []domain.Payment
+			if tt.paidThroughAPI {
+				authorizationID, _ := gateway.Authorize(payment.AuthorizeRequest{OrderID: 7, Amount: domain.NewMoney(28000, "RUB"), Source: "tok_visa"})
+				transactionID, _ := gateway.Capture(authorizationID, domain.NewMoney(28000, "RUB"))
+				payments = []domain.Payment{{ID: 4, OrderID: 7, Status: domain.PaymentStatusCaptured, TransactionID: transactionID}}
+				for _, refund := range tt.refunds {
+					_, _ = gateway.Refund(transactionID, refund.Amount)
+				}
+			}
+			gateway.SetFailure(payment.OperationRefund, tt.refundFailure)
+
+			txOrders := new(MockOrderRepository)
+			txProducts := new(MockProductRepository)
+			txPayments := new(MockPaymentRepository)
+			txReturns := new(MockReturnRepository)
+			uow := &fakeUnitOfWork{repos: repository.Repositories{Orders: txOrders, Products: txProducts, Payments: txPayments, Returns: txReturns}}
+			returnService := NewReturnService(new(MockOrderRepository), new(MockReturnRepository), uow, gateway)
+
+			orderReturn := &domain.OrderReturn{ID: 2, OrderID: 7, Status: tt.returnStatus, Items: tt.items, Amount: domain.NewMoney(tt.amount, "RUB")}
+			txReturns.On("GetByID", uint(2)).Return(orderReturn, nil)
+			txOrders.On("GetByIDForUpdate", uint(7)).Return(returnTestOrder(tt.orderStatus), nil)
+			txReturns.On("GetByOrderID", uint(7)).Return(append(tt.previous, *orderReturn), nil).Maybe()
+			txReturns.On("GetRefundsByOrderID", uint(7)).Return(tt.refunds, nil).Maybe()
+			txProducts.On("IncrementStock", mock.Anything, mock.Anything).Return(nil).Maybe()
+			txOrders.On("UpdateStatus", uint(7), tt.expectedStatus).Return(nil).Maybe()
+			txOrders.On("AddStatusHistory", mock.Anything).Return(nil).Maybe()
+			txReturns.On("Update", mock.Anything).Return(nil).Maybe()
+			txPayments.On("GetByOrderID", uint(7)).Return(payments, nil).Maybe()
+			txPayments.On("Update", mock.Anything).Return(nil).Maybe()
+			var refund *domain.Refund
+			var createdStatus domain.RefundStatus
+			txReturns.On("CreateRefund", mock.Anything).Run(func(args mock.Arguments) {
+				refund = args.Get(0).(*domain.Refund)
+				createdStatus = refund.Status
+			}).Return(nil).Maybe()
+			txReturns.On("UpdateRefund", mock.Anything).Return(nil).Maybe()
+
+			approved, err := returnService.ApproveReturn(5, 2, "принято на склад")
+			if tt.refundFailure != payment.FailureNone {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.True(t, uow.committed)
+				assert.Equal(t, domain.ReturnStatusApproved, orderReturn.Status)
+				if assert.NotNil(t, refund) {
+					assert.Equal(t, domain.RefundStatusFailed, refund.Status)
+					assert.NotEmpty(t, refund.FailureReason)
+					assert.Empty(t, refund.TransactionID)
+				}
+				assert.Equal(t, tt.expectedPayment, payments[0].Status)
+				return
+			}
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				txReturns.AssertNotCalled(t, "CreateRefund", mock.Anything)
+				txProducts.AssertNotCalled(t, "IncrementStock", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, domain.ReturnStatusApproved, approved.Status)
+			assert.Equal(t, uint(5), *approved.ReviewedBy)
+			assert.Equal(t, domain.NewMoney(tt.expectedRefund, "RUB"), approved.Amount)
+			if tt.orderStatus != tt.expectedStatus {
+				txOrders.AssertCalled(t, "UpdateStatus", uint(7), tt.expectedStatus)
+			} else {
+				txOrders.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+			}
+			for _, line := range tt.items {
+				productID := uint(10)
+				if line.OrderItemID == 72 {
+					productID = 11
+				}
+				txProducts.AssertCalled(t, "IncrementStock", productID, line.Quantity)
+			}
+
+			if assert.NotNil(t, refund) {
+				assert.Equal(t, domain.NewMoney(tt.expectedRefund, "RUB"), refund.Amount)
+				assert.Equal(t, domain.RefundStatusSucceeded, refund.Status)
+				if tt.paidThroughAPI {
+					// Возврат сохраняется до обращения к шлюзу
+					assert.Equal(t, domain.RefundStatusPending, createdStatus)
+					assert.Equal(t, uint(4), *refund.PaymentID)
+					assert.NotEmpty(t, refund.TransactionID)
+					assert.Equal(t, tt.expectedPayment, payments[0].Status)
+				} else {
+					assert.Nil(t, refund.PaymentID)
+					assert.Empty(t, refund.TransactionID)
+				}
+			}
+		})
+	}
+}
+
+func TestRejectReturn(t *testing.T) {
+	txOrders := new(MockOrderRepository)
+	txReturns := new(MockReturnRepository)
+	uow := &fakeUnitOfWork{repos: repository.Repositories{Orders: txOrders, Returns: txReturns}}
+	returnService := NewReturnService(new(MockOrderRepository), new(MockReturnRepository), uow, payment.NewFakeGateway(nil))
+
+	_, err := returnService.RejectReturn(5, 2, "  ")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	txReturns.On("GetByID", uint(2)).Return(&domain.OrderReturn{ID: 2, OrderID: 7, Status: domain.ReturnStatusRequested}, nil)
+	txOrders.On("GetByIDForUpdate", uint(7)).Return(returnTestOrder(domain.OrderStatusDelivered), nil)
+	txReturns.On("Update", mock.Anything).Return(nil)
+
+	rejected, err := returnService.RejectReturn(5, 2, "следы использования")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ReturnStatusRejected, rejected.Status)
+	assert.Equal(t, "следы использования", rejected.ReviewNote)
+	txReturns.AssertNotCalled(t, "CreateRefund", mock.Anything)
+}
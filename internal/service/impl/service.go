@@ -2,16 +2,31 @@ package impl
 
 import (
 	"errors"
+	"fmt"
 	"shopping-cart/internal/domain"
+	"shopping-cart/internal/payment"
+	"shopping-cart/internal/pricing"
 	"shopping-cart/internal/repository"
 	"shopping-cart/internal/service"
+	"shopping-cart/internal/tax"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
 )
 
+// maxCancellationReasonLength - максимальная длина причины отмены заказа в символах
+const maxCancellationReasonLength = 500
+
 // cartService реализует интерфейс CartService
 type cartService struct {
-	cartRepo     repository.CartRepository
-	cartItemRepo repository.CartItemRepository
-	productRepo  repository.ProductRepository
+	cartRepo      repository.CartRepository
+	cartItemRepo  repository.CartItemRepository
+	productRepo   repository.ProductRepository
+	promotionRepo repository.PromotionRepository
+	uow           repository.UnitOfWork
+	pricer        pricing.Calculator
 }
 
 // orderService реализует интерфейс OrderService
@@ -20,116 +35,290 @@ type orderService struct {
 	cartRepo     repository.CartRepository
 	cartItemRepo repository.CartItemRepository
 	productRepo  repository.ProductRepository
+	addressRepo  repository.AddressRepository
+	uow          repository.UnitOfWork
+	pricer       pricing.Calculator
+	gateway      payment.Gateway
 }
 
 // productService реализует интерфейс ProductService
 type productService struct {
 	productRepo repository.ProductRepository
+	taxes       *tax.Table
 }
 
 // NewCartService создает новый экземпляр CartService
-func NewCartService(cartRepo repository.CartRepository, cartItemRepo repository.CartItemRepository, productRepo repository.ProductRepository) service.CartService {
+func NewCartService(cartRepo repository.CartRepository, cartItemRepo repository.CartItemRepository, productRepo repository.ProductRepository, promotionRepo repository.PromotionRepository, uow repository.UnitOfWork, pricer pricing.Calculator) service.CartService {
 	return &cartService{
-		cartRepo:     cartRepo,
-		cartItemRepo: cartItemRepo,
-		productRepo:  productRepo,
+		cartRepo:      cartRepo,
+		cartItemRepo:  cartItemRepo,
+		productRepo:   productRepo,
+		promotionRepo: promotionRepo,
+		uow:           uow,
+		pricer:        pricer,
 	}
 }
 
 // NewOrderService создает новый экземпляр OrderService
-func NewOrderService(orderRepo repository.OrderRepository, cartRepo repository.CartRepository, cartItemRepo repository.CartItemRepository, productRepo repository.ProductRepository) service.OrderService {
+func NewOrderService(orderRepo repository.OrderRepository, cartRepo repository.CartRepository, cartItemRepo repository.CartItemRepository, productRepo repository.ProductRepository, addressRepo repository.AddressRepository, uow repository.UnitOfWork, pricer pricing.Calculator, gateway payment.Gateway) service.OrderService {
 	return &orderService{
 		orderRepo:    orderRepo,
 		cartRepo:     cartRepo,
 		cartItemRepo: cartItemRepo,
 		productRepo:  productRepo,
+		addressRepo:  addressRepo,
+		uow:          uow,
+		pricer:       pricer,
+		gateway:      gateway,
 	}
 }
 
 // NewProductService создает новый экземпляр ProductService
-func NewProductService(productRepo repository.ProductRepository) service.ProductService {
+func NewProductService(productRepo repository.ProductRepository, taxes *tax.Table) service.ProductService {
 	return &productService{
 		productRepo: productRepo,
+		taxes:       taxes,
 	}
 }
 
-// AddItem добавляет товар в корзину пользователя
+// AddItem добавляет товар в корзину пользователя и запоминает его текущую цену
 // Если товар уже есть в корзине, увеличивает его количество
-func (s *cartService) AddItem(userID uint, productID uint, quantity int) error {
-	// Get or create cart
-	cart, err := s.cartRepo.GetByUserID(userID)
+// Количество увеличивается атомарно, а итог проверяется в той же транзакции,
+// поэтому параллельные добавления не теряют единицы и не превышают лимиты
+func (s *cartService) AddItem(owner domain.CartOwner, productID uint, quantity int) error {
+	if err := domain.ValidateLineQuantity(quantity); err != nil {
+		return err
+	}
+
+	cart, err := s.cartRepo.GetOrCreate(owner)
+	if err != nil {
+		return err
+	}
+
+	product, err := s.productRepo.GetByID(productID)
 	if err != nil {
-		cart = &domain.Cart{UserID: userID}
-		if err := s.cartRepo.Create(cart); err != nil {
+		return wrapNotFound(err, "product")
+	}
+	if err := checkStock(product, quantity); err != nil {
+		return err
+	}
+
+	return s.uow.Do(func(repos repository.Repositories) error {
+		item := &domain.CartItem{
+			CartID:    cart.ID,
+			ProductID: productID,
+			Quantity:  quantity,
+			Price:     product.Price,
+		}
+		if err := repos.CartItems.AddQuantity(item); err != nil {
+			return err
+		}
+		if err := domain.ValidateLineQuantity(item.Quantity); err != nil {
 			return err
 		}
+		return checkStock(product, item.Quantity)
+	})
+}
+
+// RemoveItem удаляет товар из корзины пользователя
+func (s *cartService) RemoveItem(owner domain.CartOwner, itemID uint) error {
+	if _, err := s.getOwnedItem(owner, itemID); err != nil {
+		return err
 	}
+	return s.cartItemRepo.Delete(itemID)
+}
 
-	// Check if product exists
-	if _, err := s.productRepo.GetByID(productID); err != nil {
+// UpdateItemQuantity устанавливает количество товара в позиции корзины
+// Количество 0 удаляет позицию
+func (s *cartService) UpdateItemQuantity(owner domain.CartOwner, itemID uint, quantity int) error {
+	if quantity == 0 {
+		return s.RemoveItem(owner, itemID)
+	}
+	if err := domain.ValidateLineQuantity(quantity); err != nil {
 		return err
 	}
 
-	// Check if item already exists in cart
-	items, err := s.cartItemRepo.GetByCartID(cart.ID)
+	item, err := s.getOwnedItem(owner, itemID)
 	if err != nil {
 		return err
 	}
 
-	for _, item := range items {
-		if item.ProductID == productID {
-			// Update quantity of existing item
-			item.Quantity += quantity
-			return s.cartItemRepo.Update(&item)
-		}
+	product, err := s.productRepo.GetByID(item.ProductID)
+	if err != nil {
+		return wrapNotFound(err, "product")
+	}
+	if err := checkStock(product, quantity); err != nil {
+		return err
 	}
 
-	// Create new cart item if not exists
-	cartItem := &domain.CartItem{
-		CartID:    cart.ID,
-		ProductID: productID,
-		Quantity:  quantity,
+	item.Quantity = quantity
+	return s.cartItemRepo.Update(item)
+}
+
+// AcceptPriceChanges сохраняет в позициях корзины цены, которые покупатель подтвердил,
+// и возвращает пересчитанную корзину. Подтвердить можно только текущую цену товара:
+// если она снова изменилась, возвращается price_changed с новым списком и ни одна цена не сохраняется
+func (s *cartService) AcceptPriceChanges(owner domain.CartOwner, accepted []service.AcceptedPrice) (*domain.Cart, error) {
+	if len(accepted) == 0 {
+		return nil, domain.NewValidationError("at least one price is required")
+	}
+	cart, err := s.findCart(owner)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.cartItemRepo.Create(cartItem)
+	err = s.uow.Do(func(repos repository.Repositories) error {
+		var changes []domain.PriceChange
+		for _, price := range accepted {
+			item, err := repos.CartItems.GetByID(price.ItemID)
+			if err != nil {
+				return wrapNotFound(err, "cart item")
+			}
+			if item.CartID != cart.ID {
+				return domain.NewForbiddenError("item does not belong to user's cart")
+			}
+			product, err := repos.Products.GetByID(item.ProductID)
+			if err != nil {
+				return wrapNotFound(err, "product")
+			}
+			if product.Price != price.NewPrice {
+				changes = append(changes, domain.PriceChange{
+					ItemID:    item.ID,
+					ProductID: item.ProductID,
+					OldPrice:  item.Price,
+					NewPrice:  product.Price,
+				})
+				continue
+			}
+
+			item.Price = price.NewPrice
+			if err := repos.CartItems.Update(item); err != nil {
+				return err
+			}
+		}
+		if len(changes) > 0 {
+			return domain.NewPriceChangedError(changes...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCart(owner)
 }
 
-// RemoveItem удаляет товар из корзины пользователя
-func (s *cartService) RemoveItem(userID uint, itemID uint) error {
-	cart, err := s.cartRepo.GetByUserID(userID)
+// findCart возвращает существующую корзину пользователя или гостя
+func (s *cartService) findCart(owner domain.CartOwner) (*domain.Cart, error) {
+	var cart *domain.Cart
+	var err error
+	if owner.IsGuest() {
+		cart, err = s.cartRepo.GetByGuestToken(owner.GuestToken)
+	} else {
+		cart, err = s.cartRepo.GetByUserID(owner.UserID)
+	}
 	if err != nil {
-		return err
+		return nil, wrapNotFound(err, "cart")
+	}
+	return cart, nil
+}
+
+// getOwnedItem возвращает позицию корзины, проверяя, что она принадлежит владельцу корзины
+func (s *cartService) getOwnedItem(owner domain.CartOwner, itemID uint) (*domain.CartItem, error) {
+	cart, err := s.findCart(owner)
+	if err != nil {
+		return nil, err
 	}
 
 	item, err := s.cartItemRepo.GetByID(itemID)
 	if err != nil {
-		return err
+		return nil, wrapNotFound(err, "cart item")
 	}
 
 	if item.CartID != cart.ID {
-		return errors.New("item does not belong to user's cart")
+		return nil, domain.NewForbiddenError("item does not belong to user's cart")
 	}
+	return item, nil
+}
 
-	return s.cartItemRepo.Delete(itemID)
+// GetCart возвращает корзину пользователя или гостя с рассчитанными итогами
+// Если корзины пользователя не существует, создает новую. Корзина гостя при чтении
+// не создается: гость без корзины получает пустую, она сохраняется при первом изменении
+func (s *cartService) GetCart(owner domain.CartOwner) (*domain.Cart, error) {
+	cart, err := s.loadCart(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion, err := cartPromotion(s.promotionRepo, cart, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.pricer.Calculate(pricing.Request{Lines: pricing.LinesFromCart(cart.Items), Promotion: promotion})
+	if err != nil {
+		return nil, err
+	}
+	cart.Summary = summary
+	return cart, nil
+}
+
+// loadCart возвращает корзину для чтения
+func (s *cartService) loadCart(owner domain.CartOwner) (*domain.Cart, error) {
+	if !owner.IsGuest() {
+		return s.cartRepo.GetOrCreate(owner)
+	}
+	if owner.GuestToken == "" {
+		return &domain.Cart{Items: []domain.CartItem{}}, nil
+	}
+	cart, err := s.findCart(owner)
+	if errors.Is(err, domain.ErrNotFound) {
+		return &domain.Cart{Items: []domain.CartItem{}}, nil
+	}
+	return cart, err
 }
 
-// GetCart возвращает корзину пользователя
-// Если корзина не существует, создает новую
-func (s *cartService) GetCart(userID uint) (*domain.Cart, error) {
-	cart, err := s.cartRepo.GetByUserID(userID)
+// ApplyCoupon применяет купон к корзине пользователя
+// Купон заменяет ранее примененный; корзина должна удовлетворять условиям акции.
+// Лимит использований на пользователя для гостя проверяется при оформлении заказа
+func (s *cartService) ApplyCoupon(owner domain.CartOwner, code string) (*domain.Cart, error) {
+	cart, err := s.cartRepo.GetOrCreate(owner)
 	if err != nil {
-		// Если корзина не найдена, создаем новую
-		cart = &domain.Cart{UserID: userID}
-		if err := s.cartRepo.Create(cart); err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
+
+	promotion, err := resolveCoupon(s.promotionRepo, code, owner.UserID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.pricer.Calculate(pricing.Request{Lines: pricing.LinesFromCart(cart.Items), Promotion: promotion})
+	if err != nil {
+		return nil, err
+	}
+	if !promotion.Qualifies(summary.Subtotal) {
+		return nil, service.ErrCouponNotApplicable
+	}
+
+	if err := s.cartRepo.SetCouponCode(cart.ID, promotion.Code); err != nil {
+		return nil, err
+	}
+	cart.CouponCode = promotion.Code
+	cart.Summary = summary
 	return cart, nil
 }
 
+// RemoveCoupon снимает купон с корзины пользователя
+func (s *cartService) RemoveCoupon(owner domain.CartOwner) error {
+	cart, err := s.findCart(owner)
+	if err != nil {
+		return err
+	}
+	return s.cartRepo.SetCouponCode(cart.ID, "")
+}
+
 // ClearCart очищает корзину пользователя
-func (s *cartService) ClearCart(userID uint) error {
-	cart, err := s.cartRepo.GetByUserID(userID)
+func (s *cartService) ClearCart(owner domain.CartOwner) error {
+	cart, err := s.findCart(owner)
 	if err != nil {
 		return err
 	}
@@ -148,75 +337,248 @@ func (s *cartService) ClearCart(userID uint) error {
 	return nil
 }
 
-// CreateOrder создает новый заказ из корзины пользователя
-// После создания заказа корзина очищается
-func (s *orderService) CreateOrder(userID uint) (*domain.Order, error) {
-	cart, err := s.cartRepo.GetByUserID(userID)
+// MergeCart переносит позиции гостевой корзины в корзину пользователя и удаляет гостевую
+// Количество одного товара складывается в пределах domain.MaxLineQuantity, остатки
+// проверяются при оформлении заказа. Купон гостя переносится, если у пользователя своего нет.
+// Гостевая корзина блокируется, поэтому повторное слияние по тому же токену ничего не добавит
+func (s *cartService) MergeCart(guestToken string, userID uint) (*domain.Cart, error) {
+	err := s.uow.Do(func(repos repository.Repositories) error {
+		guest, err := repos.Carts.GetByGuestTokenForUpdate(guestToken)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		cart, err := repos.Carts.GetOrCreate(domain.CartOwner{UserID: userID})
+		if err != nil {
+			return err
+		}
+		for _, line := range guest.Items {
+			item := &domain.CartItem{
+				CartID:    cart.ID,
+				ProductID: line.ProductID,
+				Quantity:  line.Quantity,
+				Price:     line.Price,
+			}
+			if err := repos.CartItems.AddQuantity(item); err != nil {
+				return err
+			}
+			if item.Quantity > domain.MaxLineQuantity {
+				item.Quantity = domain.MaxLineQuantity
+				if err := repos.CartItems.Update(item); err != nil {
+					return err
+				}
+			}
+		}
+		if cart.CouponCode == "" && guest.CouponCode != "" {
+			if err := repos.Carts.SetCouponCode(cart.ID, guest.CouponCode); err != nil {
+				return err
+			}
+		}
+		return repos.Carts.Delete(guest.ID)
+	})
 	if err != nil {
 		return nil, err
 	}
+	return s.GetCart(domain.CartOwner{UserID: userID})
+}
 
-	cartItems, err := s.cartItemRepo.GetByCartID(cart.ID)
+// CreateOrder создает новый заказ из корзины пользователя
+// Если цена товара изменилась после добавления в корзину, заказ не оформляется
+// до подтверждения новых цен через AcceptPriceChanges
+// Заказ, его позиции, списание остатков и удаление корзины выполняются
+// в одной транзакции: при любой ошибке ничего из этого не сохраняется
+// В заказ копируются адреса доставки и оплаты, поэтому последующее изменение
+// адресной книги не меняет уже оформленные заказы
+func (s *orderService) CreateOrder(userID uint, req service.CheckoutRequest) (*domain.Order, error) {
+	if req.ShippingMethod == "" {
+		return nil, domain.NewValidationError("shipping method is required")
+	}
+	shippingAddress, err := getOwnedAddress(s.addressRepo, userID, req.ShippingAddressID)
 	if err != nil {
 		return nil, err
 	}
-
-	if len(cartItems) == 0 {
-		return nil, errors.New("cart is empty")
+	billingAddress := shippingAddress
+	if req.BillingAddressID != 0 && req.BillingAddressID != req.ShippingAddressID {
+		if billingAddress, err = getOwnedAddress(s.addressRepo, userID, req.BillingAddressID); err != nil {
+			return nil, err
+		}
 	}
 
-	// Calculate total
-	var total float64
-	for _, item := range cartItems {
-		product, err := s.productRepo.GetByID(item.ProductID)
+	var orderID uint
+	err = s.uow.Do(func(repos repository.Repositories) error {
+		// Блокировка корзины не дает параллельным оформлениям создать два заказа из одной корзины:
+		// второе дождется фиксации первого и уже не найдет удаленную корзину
+		cart, err := repos.Carts.GetByUserIDForUpdate(userID)
 		if err != nil {
-			return nil, err
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrEmptyCart
+			}
+			return err
 		}
-		total += float64(item.Quantity) * product.Price
-	}
 
-	// Create order
-	order := &domain.Order{
-		UserID: userID,
-		Status: "pending",
-		Total:  total,
-	}
+		cartItems, err := repos.CartItems.GetByCartID(cart.ID)
+		if err != nil {
+			return err
+		}
 
-	if err := s.orderRepo.Create(order); err != nil {
-		return nil, err
-	}
+		if len(cartItems) == 0 {
+			return domain.ErrEmptyCart
+		}
+
+		// Price the cart with current product data, the same way the cart view does
+		lines := make([]pricing.Line, 0, len(cartItems))
+		var changes []domain.PriceChange
+		for _, item := range cartItems {
+			product, err := repos.Products.GetByID(item.ProductID)
+			if err != nil {
+				return wrapNotFound(err, "product")
+			}
+			if item.Price != product.Price {
+				changes = append(changes, domain.PriceChange{
+					ItemID:    item.ID,
+					ProductID: item.ProductID,
+					OldPrice:  item.Price,
+					NewPrice:  product.Price,
+				})
+			}
+			lines = append(lines, pricing.Line{ItemID: item.ID, Product: product, Quantity: item.Quantity, SavedPrice: item.Price})
+		}
+		// Цены, изменившиеся после добавления в корзину, покупатель должен подтвердить
+		if len(changes) > 0 {
+			return domain.NewPriceChangedError(changes...)
+		}
 
-	// Create order items
-	for _, item := range cartItems {
-		product, err := s.productRepo.GetByID(item.ProductID)
+		promotion, err := cartPromotion(repos.Promotions, cart, time.Now())
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		orderItem := &domain.OrderItem{
-			OrderID:   order.ID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     product.Price,
+		summary, err := s.pricer.Calculate(pricing.Request{
+			Lines:          lines,
+			Promotion:      promotion,
+			Region:         shippingAddress.Country,
+			ShippingMethod: req.ShippingMethod,
+		})
+		if err != nil {
+			return err
 		}
 
-		if err := s.orderRepo.CreateOrderItem(orderItem); err != nil {
-			return nil, err
+		orderItems := make([]domain.OrderItem, 0, len(summary.Lines))
+		for _, line := range summary.Lines {
+			orderItems = append(orderItems, domain.OrderItem{
+				ProductID: line.ProductID,
+				Quantity:  line.Quantity,
+				Price:     line.UnitPrice,
+				Discount:  line.Discount,
+				TaxRate:   line.TaxRate,
+				Tax:       line.Tax,
+			})
+		}
+
+		// Reserve stock for every line; report all lines that can't be fulfilled
+		if err := reserveStock(repos.Products, cartItems); err != nil {
+			return err
+		}
+
+		// Create order
+		order := &domain.Order{
+			UserID:       userID,
+			Status:       domain.OrderStatusPending,
+			Subtotal:     summary.Subtotal,
+			Discount:     summary.Discount,
+			Tax:          summary.Tax,
+			TaxInclusive: summary.TaxInclusive,
+			Total:        summary.Total,
+
+			ShippingMethod:  req.ShippingMethod,
+			Shipping:        summary.Shipping,
+			ShippingAddress: shippingAddress.PostalAddress,
+			BillingAddress:  billingAddress.PostalAddress,
+		}
+
+		if err := repos.Orders.Create(order); err != nil {
+			return err
 		}
-	}
 
-	// Clear cart after order creation
-	if err := s.cartRepo.Delete(cart.ID); err != nil {
+		if err := recordStatusChange(repos.Orders, order.ID, "", domain.OrderStatusPending, userID); err != nil {
+			return err
+		}
+
+		// Create order items
+		for i := range orderItems {
+			orderItems[i].OrderID = order.ID
+			if err := repos.Orders.CreateOrderItem(&orderItems[i]); err != nil {
+				return err
+			}
+		}
+
+		// Persist applied discounts and count coupon usage
+		for _, applied := range summary.Discounts {
+			promotionID := applied.PromotionID
+			discount := &domain.OrderDiscount{
+				OrderID:     order.ID,
+				PromotionID: &promotionID,
+				Code:        applied.Code,
+				Description: applied.Description,
+				Amount:      applied.Amount,
+			}
+			if err := repos.Orders.CreateOrderDiscount(discount); err != nil {
+				return err
+			}
+
+			redeemed, err := repos.Promotions.Redeem(&domain.PromotionRedemption{
+				PromotionID: applied.PromotionID,
+				UserID:      userID,
+				OrderID:     order.ID,
+			})
+			if err != nil {
+				return err
+			}
+			if !redeemed {
+				return service.ErrCouponLimitReached
+			}
+		}
+
+		// Clear cart after order creation
+		if err := repos.Carts.Delete(cart.ID); err != nil {
+			return err
+		}
+
+		orderID = order.ID
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Get the complete order with items
-	return s.orderRepo.GetByID(order.ID)
+	return s.orderRepo.GetByID(orderID)
 }
 
 // GetOrder возвращает заказ по его ID
-func (s *orderService) GetOrder(orderID uint) (*domain.Order, error) {
-	return s.orderRepo.GetByID(orderID)
+// Покупатель может просматривать только собственные заказы
+func (s *orderService) GetOrder(userID uint, role domain.Role, orderID uint) (*domain.Order, error) {
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, wrapNotFound(err, "order")
+	}
+
+	if order.UserID != userID && !role.Can(domain.PermissionReadAllOrders) {
+		return nil, service.ErrForbidden
+	}
+	return order, nil
+}
+
+// GetOrderHistory возвращает журнал изменений статуса заказа
+// Права доступа те же, что и у GetOrder
+func (s *orderService) GetOrderHistory(userID uint, role domain.Role, orderID uint) ([]domain.OrderStatusHistory, error) {
+	if _, err := s.GetOrder(userID, role, orderID); err != nil {
+		return nil, err
+	}
+	return s.orderRepo.GetStatusHistory(orderID)
 }
 
 // GetUserOrders возвращает все заказы пользователя
@@ -224,32 +586,243 @@ func (s *orderService) GetUserOrders(userID uint) ([]domain.Order, error) {
 	return s.orderRepo.GetByUserID(userID)
 }
 
-// UpdateOrderStatus обновляет статус заказа
-func (s *orderService) UpdateOrderStatus(orderID uint, status string) error {
-	return s.orderRepo.UpdateStatus(orderID, status)
+// UpdateOrderStatus переводит заказ в новый статус и записывает переход в журнал
+// actorID - пользователь, выполнивший изменение, 0 - изменение системой
+// Отмена требует причины и выполняется только через CancelOrder
+func (s *orderService) UpdateOrderStatus(actorID uint, orderID uint, status domain.OrderStatus) error {
+	if !status.IsValid() {
+		return domain.NewValidationError(fmt.Sprintf("unknown order status %q", status))
+	}
+	if status == domain.OrderStatusCancelled {
+		return domain.NewValidationError("orders are cancelled through the cancel endpoint with a reason")
+	}
+
+	return s.uow.Do(func(repos repository.Repositories) error {
+		order, err := repos.Orders.GetByIDForUpdate(orderID)
+		if err != nil {
+			return wrapNotFound(err, "order")
+		}
+
+		if !order.Status.CanTransitionTo(status) {
+			return domain.NewConflictError(fmt.Sprintf("cannot change order status from %s to %s", order.Status, status))
+		}
+
+		if err := repos.Orders.UpdateStatus(orderID, status); err != nil {
+			return wrapNotFound(err, "order")
+		}
+		return recordStatusChange(repos.Orders, orderID, order.Status, status, actorID)
+	})
+}
+
+// CancelOrder отменяет заказ с обязательным указанием причины
+// Покупатель может отменить только собственный заказ и только до его отправки
+func (s *orderService) CancelOrder(userID uint, role domain.Role, orderID uint, reason string) (*domain.Order, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, domain.NewValidationError("cancellation reason is required")
+	}
+	if utf8.RuneCountInString(reason) > maxCancellationReasonLength {
+		return nil, domain.NewValidationError(fmt.Sprintf("cancellation reason must not exceed %d characters", maxCancellationReasonLength))
+	}
+
+	var refund *domain.Refund
+	var captured *domain.Payment
+	err := s.uow.Do(func(repos repository.Repositories) error {
+		order, err := repos.Orders.GetByIDForUpdate(orderID)
+		if err != nil {
+			return wrapNotFound(err, "order")
+		}
+		if order.UserID != userID && !role.Can(domain.PermissionManageOrders) {
+			return service.ErrForbidden
+		}
+		if !order.Status.IsCancellable() {
+			return service.ErrOrderNotCancellable
+		}
+		refund, captured, err = s.cancel(repos, order, userID, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if captured != nil {
+		if err := settleRefund(s.uow, s.gateway, captured, refund, true); err != nil {
+			return nil, err
+		}
+	}
+	return s.orderRepo.GetByID(orderID)
+}
+
+// cancel отменяет заказ: возвращает остатки на склад, сохраняет причину и время отмены.
+// Если заказ был оплачен через платежный шлюз, сохраняет возврат всей суммы как pending
+// и возвращает его вместе со списанным платежом: провести возврат через шлюз
+// нужно после фиксации транзакции функцией settleRefund
+func (s *orderService) cancel(repos repository.Repositories, order *domain.Order, actorID uint, reason string) (*domain.Refund, *domain.Payment, error) {
+	if err := releaseStock(repos.Products, order.Items); err != nil {
+		return nil, nil, err
+	}
+	if err := repos.Orders.UpdateStatus(order.ID, domain.OrderStatusCancelled); err != nil {
+		return nil, nil, wrapNotFound(err, "order")
+	}
+	if err := repos.Orders.SetCancellation(order.ID, reason, time.Now()); err != nil {
+		return nil, nil, err
+	}
+	if err := recordStatusChange(repos.Orders, order.ID, order.Status, domain.OrderStatusCancelled, actorID); err != nil {
+		return nil, nil, err
+	}
+
+	captured, err := capturedPayment(repos.Payments, order.ID)
+	if err != nil || captured == nil {
+		return nil, nil, err
+	}
+	refund := &domain.Refund{
+		OrderID: order.ID,
+		Amount:  captured.Amount,
+	}
+	if actorID != 0 {
+		refund.CreatedBy = &actorID
+	}
+	pendingRefund(captured, refund)
+	if err := repos.Returns.CreateRefund(refund); err != nil {
+		return nil, nil, err
+	}
+	return refund, captured, nil
+}
+
+// recordStatusChange добавляет запись в журнал статусов заказа
+func recordStatusChange(orders repository.OrderRepository, orderID uint, from, to domain.OrderStatus, actorID uint) error {
+	entry := &domain.OrderStatusHistory{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+	}
+	if actorID != 0 {
+		entry.ChangedBy = &actorID
+	}
+	return orders.AddStatusHistory(entry)
 }
 
 // CreateProduct создает новый товар
 func (s *productService) CreateProduct(product *domain.Product) error {
+	if err := normalizePrice(&product.Price); err != nil {
+		return err
+	}
+	if err := s.normalizeTaxCategory(product); err != nil {
+		return err
+	}
+	if product.WeightGrams < 0 {
+		return domain.NewValidationError("weight must not be negative")
+	}
+	product.Category = strings.TrimSpace(product.Category)
 	return s.productRepo.Create(product)
 }
 
 // GetProduct возвращает товар по его ID
 func (s *productService) GetProduct(id uint) (*domain.Product, error) {
-	return s.productRepo.GetByID(id)
+	product, err := s.productRepo.GetByID(id)
+	if err != nil {
+		return nil, wrapNotFound(err, "product")
+	}
+	return product, nil
 }
 
-// GetAllProducts возвращает все товары
-func (s *productService) GetAllProducts() ([]domain.Product, error) {
-	return s.productRepo.GetAll()
+// ListProducts возвращает страницу каталога и число всех товаров, подходящих под фильтр
+// Репозиторий запрашивается на один товар больше страницы: по нему видно, есть ли следующая
+func (s *productService) ListProducts(query domain.ProductQuery) (*domain.ProductPage, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+
+	lookahead := query
+	lookahead.Limit++
+	products, err := s.productRepo.List(lookahead)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.productRepo.Count(query.ProductFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.ProductPage{
+		Items:  products,
+		Total:  total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	if len(products) > query.Limit {
+		page.Items = products[:query.Limit]
+		page.NextCursor = domain.NewProductCursor(query.Sort, &page.Items[query.Limit-1]).Encode()
+	}
+	if page.Items == nil {
+		page.Items = []domain.Product{}
+	}
+	return page, nil
 }
 
-// UpdateProduct обновляет информацию о товаре
+// UpdateProduct обновляет информацию о товаре с идентификатором product.ID
 func (s *productService) UpdateProduct(product *domain.Product) error {
-	return s.productRepo.Update(product)
+	if err := normalizePrice(&product.Price); err != nil {
+		return err
+	}
+	if err := s.normalizeTaxCategory(product); err != nil {
+		return err
+	}
+	if product.WeightGrams < 0 {
+		return domain.NewValidationError("weight must not be negative")
+	}
+	product.Category = strings.TrimSpace(product.Category)
+
+	existing, err := s.productRepo.GetByID(product.ID)
+	if err != nil {
+		return wrapNotFound(err, "product")
+	}
+
+	existing.Name = product.Name
+	existing.Description = product.Description
+	existing.Price = product.Price
+	existing.Category = product.Category
+	existing.TaxCategory = product.TaxCategory
+	existing.WeightGrams = product.WeightGrams
+
+	if err := s.productRepo.Update(existing); err != nil {
+		return err
+	}
+	*product = *existing
+	return nil
 }
 
 // DeleteProduct удаляет товар
 func (s *productService) DeleteProduct(id uint) error {
-	return s.productRepo.Delete(id)
+	return wrapNotFound(s.productRepo.Delete(id), "product")
+}
+
+// SetStock устанавливает остаток товара на складе
+func (s *productService) SetStock(id uint, stock int) error {
+	if stock < 0 {
+		return domain.NewValidationError("stock must not be negative")
+	}
+	return wrapNotFound(s.productRepo.SetStock(id, stock), "product")
+}
+
+// normalizeTaxCategory проверяет налоговую категорию товара
+// Товар без категории облагается по основной ставке
+func (s *productService) normalizeTaxCategory(product *domain.Product) error {
+	if product.TaxCategory == "" {
+		product.TaxCategory = tax.DefaultCategory
+	}
+	if !s.taxes.HasCategory(product.TaxCategory) {
+		return domain.NewValidationError(fmt.Sprintf("unknown tax category %q", product.TaxCategory))
+	}
+	return nil
+}
+
+// normalizePrice проверяет цену товара и подставляет валюту по умолчанию
+func normalizePrice(price *domain.Money) error {
+	if price.Currency == "" {
+		price.Currency = domain.DefaultCurrency
+	}
+	if price.IsNegative() {
+		return domain.NewValidationError("price must not be negative")
+	}
+	return nil
 }
@@ -1,13 +1,52 @@
 package impl
 
 import (
+	"errors"
+	"maps"
 	"shopping-cart/internal/domain"
+	"shopping-cart/internal/payment"
+	"shopping-cart/internal/pricing"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+	"shopping-cart/internal/shipping"
+	"shopping-cart/internal/tax"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
+// zeroTaxes - налоговая таблица с нулевыми ставками, чтобы налог не влиял на суммы в тестах
+var zeroTaxes = &tax.Table{
+	Mode:          tax.ModeExclusive,
+	DefaultRegion: "RU",
+	Regions:       map[string]tax.Rates{"RU": {tax.DefaultCategory: 0}},
+}
+
+// newTestEngine создает расчет корзины без налога и с бесплатной доставкой по России,
+// чтобы суммы в тестах зависели только от цен и скидок
+func newTestEngine() *pricing.Engine {
+	rates, err := shipping.NewTable("RUB", map[string][]string{"domestic": {"RU"}}, map[string]shipping.Method{
+		"standard": {Rates: map[string][]shipping.Rate{"domestic": {{MaxWeightGrams: 100000, Price: "0"}}}},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return pricing.NewEngine(zeroTaxes, rates)
+}
+
+// customer - покупатель, с корзиной которого работают тесты
+var customer = domain.CartOwner{UserID: 1}
+
+// uintPtr возвращает указатель на значение для необязательных полей моделей
+func uintPtr(value uint) *uint {
+	return &value
+}
+
 // MockCartRepository - мок репозитория корзины
 type MockCartRepository struct {
 	mock.Mock
@@ -34,16 +73,66 @@ func (m *MockCartRepository) GetByUserID(userID uint) (*domain.Cart, error) {
 	return args.Get(0).(*domain.Cart), args.Error(1)
 }
 
+func (m *MockCartRepository) GetByGuestToken(token string) (*domain.Cart, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Cart), args.Error(1)
+}
+
+func (m *MockCartRepository) GetByGuestTokenForUpdate(token string) (*domain.Cart, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Cart), args.Error(1)
+}
+
+func (m *MockCartRepository) GetByUserIDForUpdate(userID uint) (*domain.Cart, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Cart), args.Error(1)
+}
+
+func (m *MockCartRepository) GetOrCreate(owner domain.CartOwner) (*domain.Cart, error) {
+	args := m.Called(owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Cart), args.Error(1)
+}
+
 func (m *MockCartRepository) Update(cart *domain.Cart) error {
 	args := m.Called(cart)
 	return args.Error(0)
 }
 
+func (m *MockCartRepository) SetCouponCode(id uint, code string) error {
+	args := m.Called(id, code)
+	return args.Error(0)
+}
+
 func (m *MockCartRepository) Delete(id uint) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockCartRepository) DeleteInactive(before time.Time, limit int) (int64, error) {
+	args := m.Called(before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCartRepository) GetAbandoned(before time.Time, limit int) ([]domain.AbandonedCart, error) {
+	args := m.Called(before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.AbandonedCart), args.Error(1)
+}
+
 // MockCartItemRepository - мок репозитория элементов корзины
 type MockCartItemRepository struct {
 	mock.Mock
@@ -67,6 +156,11 @@ func (m *MockCartItemRepository) GetByCartID(cartID uint) ([]domain.CartItem, er
 	return args.Get(0).([]domain.CartItem), args.Error(1)
 }
 
+func (m *MockCartItemRepository) AddQuantity(item *domain.CartItem) error {
+	args := m.Called(item)
+	return args.Error(0)
+}
+
 func (m *MockCartItemRepository) Update(item *domain.CartItem) error {
 	args := m.Called(item)
 	return args.Error(0)
@@ -95,11 +189,16 @@ func (m *MockProductRepository) GetByID(id uint) (*domain.Product, error) {
 	return args.Get(0).(*domain.Product), args.Error(1)
 }
 
-func (m *MockProductRepository) GetAll() ([]domain.Product, error) {
-	args := m.Called()
+func (m *MockProductRepository) List(query domain.ProductQuery) ([]domain.Product, error) {
+	args := m.Called(query)
 	return args.Get(0).([]domain.Product), args.Error(1)
 }
 
+func (m *MockProductRepository) Count(filter domain.ProductFilter) (int64, error) {
+	args := m.Called(filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockProductRepository) Update(product *domain.Product) error {
 	args := m.Called(product)
 	return args.Error(0)
@@ -110,58 +209,1064 @@ func (m *MockProductRepository) Delete(id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockProductRepository) SetStock(id uint, stock int) error {
+	args := m.Called(id, stock)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) DecrementStock(id uint, quantity int) (bool, error) {
+	args := m.Called(id, quantity)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockProductRepository) IncrementStock(id uint, quantity int) error {
+	args := m.Called(id, quantity)
+	return args.Error(0)
+}
+
+// MockOrderRepository - мок репозитория заказов
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) Create(order *domain.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetByID(id uint) (*domain.Order, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetByUserID(userID uint) ([]domain.Order, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]domain.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetByIDForUpdate(id uint) (*domain.Order, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) Update(order *domain.Order) error {
+	args := m.Called(order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) UpdateStatus(id uint, status domain.OrderStatus) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) SetCancellation(id uint, reason string, cancelledAt time.Time) error {
+	args := m.Called(id, reason, cancelledAt)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) AddStatusHistory(entry *domain.OrderStatusHistory) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetStatusHistory(orderID uint) ([]domain.OrderStatusHistory, error) {
+	args := m.Called(orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.OrderStatusHistory), args.Error(1)
+}
+
+func (m *MockOrderRepository) CreateOrderItem(item *domain.OrderItem) error {
+	args := m.Called(item)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) CreateOrderDiscount(discount *domain.OrderDiscount) error {
+	args := m.Called(discount)
+	return args.Error(0)
+}
+
+// fakeUnitOfWork - фейковая транзакция, передающая в fn репозитории транзакции
+// и запоминающая, была ли она зафиксирована или откачена
+type fakeUnitOfWork struct {
+	repos      repository.Repositories
+	committed  bool
+	rolledBack bool
+}
+
+func (u *fakeUnitOfWork) Do(fn func(repos repository.Repositories) error) error {
+	if err := fn(u.repos); err != nil {
+		u.rolledBack = true
+		return err
+	}
+	u.committed = true
+	return nil
+}
+
 // Тесты для CartService
 func TestAddItem(t *testing.T) {
-	mockCartRepo := new(MockCartRepository)
-	mockCartItemRepo := new(MockCartItemRepository)
+	tests := []struct {
+		name          string
+		quantity      int
+		stock         int
+		lineQuantity  int
+		expectUpsert  bool
+		expectedError error
+	}{
+		{name: "Успешное добавление товара", quantity: 2, stock: 10, lineQuantity: 2, expectUpsert: true},
+		{name: "Увеличение количества в существующей позиции", quantity: 2, stock: 10, lineQuantity: 5, expectUpsert: true},
+		{name: "Итог позиции превышает остаток", quantity: 2, stock: 3, lineQuantity: 4, expectUpsert: true, expectedError: domain.ErrInsufficientStock},
+		{name: "Итог позиции превышает лимит", quantity: 2, stock: 500, lineQuantity: domain.MaxLineQuantity + 1, expectUpsert: true, expectedError: domain.ErrValidation},
+		{name: "Добавление больше остатка", quantity: 5, stock: 3, expectedError: domain.ErrInsufficientStock},
+		{name: "Неположительное количество", quantity: 0, stock: 10, expectedError: domain.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			mockProductRepo := new(MockProductRepository)
+			txCartItems := new(MockCartItemRepository)
+			uow := &fakeUnitOfWork{repos: repository.Repositories{CartItems: txCartItems}}
+			cartService := NewCartService(mockCartRepo, new(MockCartItemRepository), mockProductRepo, new(MockPromotionRepository), uow, newTestEngine())
+
+			mockCartRepo.On("GetOrCreate", customer).Return(&domain.Cart{ID: 1, UserID: uintPtr(1)}, nil).Maybe()
+			mockProductRepo.On("GetByID", uint(1)).Return(&domain.Product{ID: 1, Price: domain.NewMoney(10000, "RUB"), Stock: tt.stock}, nil).Maybe()
+			txCartItems.On("AddQuantity", mock.MatchedBy(func(i *domain.CartItem) bool {
+				return i.CartID == 1 && i.ProductID == 1 && i.Quantity == tt.quantity && i.Price == domain.NewMoney(10000, "RUB")
+			})).Run(func(args mock.Arguments) {
+				item := args.Get(0).(*domain.CartItem)
+				item.ID = 7
+				item.Quantity = tt.lineQuantity
+			}).Return(nil).Maybe()
+
+			err := cartService.AddItem(customer, 1, tt.quantity)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, uow.committed)
+			}
+			if tt.expectUpsert {
+				txCartItems.AssertExpectations(t)
+				assert.Equal(t, tt.expectedError != nil, uow.rolledBack)
+			} else {
+				txCartItems.AssertNotCalled(t, "AddQuantity", mock.Anything)
+			}
+		})
+	}
+}
+
+// memoryCartStore - корзины и позиции в памяти с теми же гарантиями, что и уникальные
+// индексы postgres: одна корзина на пользователя и одна позиция на товар в корзине.
+// Транзакции выполняются по очереди и при ошибке откатывают изменения позиций
+type memoryCartStore struct {
+	mu     sync.Mutex
+	tx     sync.Mutex
+	carts  map[uint]*domain.Cart
+	items  map[uint]map[uint]domain.CartItem
+	nextID uint
+}
+
+func newMemoryCartStore() *memoryCartStore {
+	return &memoryCartStore{
+		carts: make(map[uint]*domain.Cart),
+		items: make(map[uint]map[uint]domain.CartItem),
+	}
+}
+
+// memoryCarts - репозиторий корзин поверх memoryCartStore, остальные методы обслуживает мок
+type memoryCarts struct {
+	*MockCartRepository
+	store *memoryCartStore
+}
+
+func (r *memoryCarts) GetOrCreate(owner domain.CartOwner) (*domain.Cart, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	cart, ok := r.store.carts[owner.UserID]
+	if !ok {
+		r.store.nextID++
+		cart = &domain.Cart{ID: r.store.nextID, UserID: uintPtr(owner.UserID)}
+		r.store.carts[owner.UserID] = cart
+	}
+	return &domain.Cart{ID: cart.ID, UserID: cart.UserID}, nil
+}
+
+// memoryCartItems - репозиторий позиций поверх memoryCartStore, остальные методы обслуживает мок
+type memoryCartItems struct {
+	*MockCartItemRepository
+	store *memoryCartStore
+}
+
+func (r *memoryCartItems) AddQuantity(item *domain.CartItem) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if r.store.items[item.CartID] == nil {
+		r.store.items[item.CartID] = make(map[uint]domain.CartItem)
+	}
+	stored, ok := r.store.items[item.CartID][item.ProductID]
+	if !ok {
+		r.store.nextID++
+		stored = domain.CartItem{ID: r.store.nextID, CartID: item.CartID, ProductID: item.ProductID, Price: item.Price}
+	}
+	stored.Quantity += item.Quantity
+	r.store.items[item.CartID][item.ProductID] = stored
+	*item = stored
+	return nil
+}
+
+// memoryUnitOfWork выполняет транзакции над memoryCartStore по очереди
+type memoryUnitOfWork struct {
+	store *memoryCartStore
+	repos repository.Repositories
+}
+
+func (u *memoryUnitOfWork) Do(fn func(repos repository.Repositories) error) error {
+	u.store.tx.Lock()
+	defer u.store.tx.Unlock()
+
+	u.store.mu.Lock()
+	snapshot := make(map[uint]map[uint]domain.CartItem, len(u.store.items))
+	for cartID, items := range u.store.items {
+		snapshot[cartID] = maps.Clone(items)
+	}
+	u.store.mu.Unlock()
+
+	if err := fn(u.repos); err != nil {
+		u.store.mu.Lock()
+		u.store.items = snapshot
+		u.store.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func TestAddItemConcurrent(t *testing.T) {
+	const workers = 150
+
+	store := newMemoryCartStore()
+	carts := &memoryCarts{MockCartRepository: new(MockCartRepository), store: store}
+	items := &memoryCartItems{MockCartItemRepository: new(MockCartItemRepository), store: store}
+	uow := &memoryUnitOfWork{store: store, repos: repository.Repositories{Carts: carts, CartItems: items}}
 	mockProductRepo := new(MockProductRepository)
+	mockProductRepo.On("GetByID", uint(1)).Return(&domain.Product{ID: 1, Stock: 1000}, nil)
+	mockProductRepo.On("GetByID", uint(2)).Return(&domain.Product{ID: 2, Stock: 40}, nil)
+	cartService := NewCartService(carts, items, mockProductRepo, new(MockPromotionRepository), uow, newTestEngine())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- cartService.AddItem(customer, 1, 1)
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- cartService.AddItem(customer, 2, 1)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	added := 0
+	for err := range errs {
+		if err == nil {
+			added++
+			continue
+		}
+		assert.True(t, errors.Is(err, domain.ErrValidation) || errors.Is(err, domain.ErrInsufficientStock), err)
+	}
+
+	// Первый товар упирается в лимит позиции, второй - в остаток на складе
+	assert.Equal(t, domain.MaxLineQuantity+40, added)
+	assert.Len(t, store.carts, 1)
+	cartID := store.carts[1].ID
+	assert.Len(t, store.items[cartID], 2)
+	assert.Equal(t, domain.MaxLineQuantity, store.items[cartID][1].Quantity)
+	assert.Equal(t, 40, store.items[cartID][2].Quantity)
+}
+
+func TestGetCartSummary(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	cartService := NewCartService(mockCartRepo, new(MockCartItemRepository), new(MockProductRepository), new(MockPromotionRepository), &fakeUnitOfWork{}, newTestEngine())
+
+	// Цена второго товара выросла после добавления в корзину
+	mockCartRepo.On("GetOrCreate", customer).Return(&domain.Cart{ID: 1, UserID: uintPtr(1), Items: []domain.CartItem{
+		{ID: 1, ProductID: 10, Quantity: 2, Price: domain.NewMoney(1999, "RUB"), Product: domain.Product{ID: 10, Price: domain.NewMoney(1999, "RUB")}},
+		{ID: 2, ProductID: 11, Quantity: 1, Price: domain.NewMoney(450, "RUB"), Product: domain.Product{ID: 11, Price: domain.NewMoney(500, "RUB")}},
+	}}, nil)
 
-	service := NewCartService(mockCartRepo, mockCartItemRepo, mockProductRepo)
+	cart, err := cartService.GetCart(customer)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cart.Summary.ItemCount)
+	assert.Equal(t, domain.NewMoney(3998, "RUB"), cart.Summary.Lines[0].Subtotal)
+	assert.False(t, cart.Summary.Lines[0].PriceChanged)
+	assert.True(t, cart.Summary.Lines[1].PriceChanged)
+	assert.Equal(t, domain.NewMoney(450, "RUB"), cart.Summary.Lines[1].SavedPrice)
+	assert.Equal(t, domain.NewMoney(500, "RUB"), cart.Summary.Lines[1].UnitPrice)
+	assert.Equal(t, domain.NewMoney(4498, "RUB"), cart.Summary.Total)
+}
 
+func TestGetGuestCart(t *testing.T) {
+	tests := []struct {
+		name          string
+		owner         domain.CartOwner
+		expectedItems int
+	}{
+		{name: "Гость без токена получает пустую корзину", owner: domain.CartOwner{}},
+		{name: "Гость без сохраненной корзины получает пустую", owner: domain.CartOwner{GuestToken: "new-token"}},
+		{name: "Сохраненная корзина гостя", owner: domain.CartOwner{GuestToken: "guest-token"}, expectedItems: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			cartService := NewCartService(mockCartRepo, new(MockCartItemRepository), new(MockProductRepository), new(MockPromotionRepository), &fakeUnitOfWork{}, newTestEngine())
+
+			mockCartRepo.On("GetByGuestToken", "new-token").Return(nil, gorm.ErrRecordNotFound)
+			mockCartRepo.On("GetByGuestToken", "guest-token").Return(&domain.Cart{ID: 3, Items: []domain.CartItem{
+				{ID: 1, ProductID: 10, Quantity: 1, Product: domain.Product{ID: 10, Price: domain.NewMoney(500, "RUB")}},
+			}}, nil)
+
+			cart, err := cartService.GetCart(tt.owner)
+			assert.NoError(t, err)
+			assert.Len(t, cart.Items, tt.expectedItems)
+			assert.Equal(t, tt.expectedItems, cart.Summary.ItemCount)
+			mockCartRepo.AssertNotCalled(t, "GetOrCreate", mock.Anything)
+		})
+	}
+}
+
+func TestUpdateItemQuantity(t *testing.T) {
 	tests := []struct {
 		name          string
-		userID        uint
-		productID     uint
 		quantity      int
-		setupMocks    func()
+		itemCartID    uint
+		setupMocks    func(items *MockCartItemRepository, products *MockProductRepository)
 		expectedError error
 	}{
 		{
-			name:      "Успешное добавление товара",
-			userID:    1,
-			productID: 1,
-			quantity:  2,
-			setupMocks: func() {
-				mockCartRepo.On("GetByUserID", uint(1)).Return(&domain.Cart{ID: 1, UserID: 1}, nil)
-				mockProductRepo.On("GetByID", uint(1)).Return(&domain.Product{ID: 1, Price: 100}, nil)
-				mockCartItemRepo.On("GetByCartID", uint(1)).Return([]domain.CartItem{}, nil)
-				mockCartItemRepo.On("Create", mock.AnythingOfType("*domain.CartItem")).Return(nil)
+			name:       "Установка нового количества",
+			quantity:   5,
+			itemCartID: 1,
+			setupMocks: func(items *MockCartItemRepository, products *MockProductRepository) {
+				products.On("GetByID", uint(10)).Return(&domain.Product{ID: 10, Stock: 10}, nil)
+				items.On("Update", mock.MatchedBy(func(i *domain.CartItem) bool {
+					return i.ID == 7 && i.Quantity == 5
+				})).Return(nil)
 			},
-			expectedError: nil,
+		},
+		{
+			name:       "Количество 0 удаляет позицию",
+			quantity:   0,
+			itemCartID: 1,
+			setupMocks: func(items *MockCartItemRepository, products *MockProductRepository) {
+				items.On("Delete", uint(7)).Return(nil)
+			},
+		},
+		{
+			name:          "Превышение лимита на позицию",
+			quantity:      domain.MaxLineQuantity + 1,
+			itemCartID:    1,
+			expectedError: domain.ErrValidation,
+		},
+		{
+			name:          "Отрицательное количество",
+			quantity:      -1,
+			itemCartID:    1,
+			expectedError: domain.ErrValidation,
+		},
+		{
+			name:       "Недостаточно товара на складе",
+			quantity:   5,
+			itemCartID: 1,
+			setupMocks: func(items *MockCartItemRepository, products *MockProductRepository) {
+				products.On("GetByID", uint(10)).Return(&domain.Product{ID: 10, Stock: 3}, nil)
+			},
+			expectedError: domain.ErrInsufficientStock,
+		},
+		{
+			name:          "Позиция из чужой корзины",
+			quantity:      5,
+			itemCartID:    2,
+			expectedError: domain.ErrForbidden,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.setupMocks()
-			err := service.AddItem(tt.userID, tt.productID, tt.quantity)
+			mockCartRepo := new(MockCartRepository)
+			mockCartItemRepo := new(MockCartItemRepository)
+			mockProductRepo := new(MockProductRepository)
+			cartService := NewCartService(mockCartRepo, mockCartItemRepo, mockProductRepo, new(MockPromotionRepository), &fakeUnitOfWork{}, newTestEngine())
+
+			mockCartRepo.On("GetByUserID", uint(1)).Return(&domain.Cart{ID: 1, UserID: uintPtr(1)}, nil).Maybe()
+			mockCartItemRepo.On("GetByID", uint(7)).Return(&domain.CartItem{ID: 7, CartID: tt.itemCartID, ProductID: 10, Quantity: 2}, nil).Maybe()
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockCartItemRepo, mockProductRepo)
+			}
+
+			err := cartService.UpdateItemQuantity(customer, 7, tt.quantity)
 			if tt.expectedError != nil {
-				assert.Error(t, err)
-				assert.Equal(t, tt.expectedError, err)
+				assert.ErrorIs(t, err, tt.expectedError)
+				mockCartItemRepo.AssertNotCalled(t, "Update", mock.Anything)
+				mockCartItemRepo.AssertNotCalled(t, "Delete", mock.Anything)
 				return
 			}
 			assert.NoError(t, err)
-			mockCartRepo.AssertExpectations(t)
 			mockCartItemRepo.AssertExpectations(t)
 			mockProductRepo.AssertExpectations(t)
 		})
 	}
 }
 
+// Тесты для OrderService
+func TestGuestCart(t *testing.T) {
+	guest := domain.CartOwner{GuestToken: "guest-token"}
+
+	tests := []struct {
+		name          string
+		itemCartID    uint
+		cartErr       error
+		expectDelete  bool
+		expectedError error
+	}{
+		{name: "Гость удаляет позицию своей корзины", itemCartID: 3, expectDelete: true},
+		{name: "Позиция из чужой корзины", itemCartID: 1, expectedError: domain.ErrForbidden},
+		{name: "Корзины по токену нет", cartErr: gorm.ErrRecordNotFound, expectedError: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			mockCartItemRepo := new(MockCartItemRepository)
+			cartService := NewCartService(mockCartRepo, mockCartItemRepo, new(MockProductRepository), new(MockPromotionRepository), &fakeUnitOfWork{}, newTestEngine())
+
+			if tt.cartErr != nil {
+				mockCartRepo.On("GetByGuestToken", "guest-token").Return(nil, tt.cartErr)
+			} else {
+				mockCartRepo.On("GetByGuestToken", "guest-token").Return(&domain.Cart{ID: 3, GuestToken: &guest.GuestToken}, nil)
+			}
+			mockCartItemRepo.On("GetByID", uint(7)).Return(&domain.CartItem{ID: 7, CartID: tt.itemCartID, ProductID: 10}, nil).Maybe()
+			mockCartItemRepo.On("Delete", uint(7)).Return(nil).Maybe()
+
+			err := cartService.RemoveItem(guest, 7)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.expectDelete {
+				mockCartItemRepo.AssertCalled(t, "Delete", uint(7))
+			} else {
+				mockCartItemRepo.AssertNotCalled(t, "Delete", mock.Anything)
+			}
+			mockCartRepo.AssertNotCalled(t, "GetByUserID", mock.Anything)
+		})
+	}
+}
+
+func TestMergeCart(t *testing.T) {
+	guestCart := &domain.Cart{ID: 3, CouponCode: "SALE10", Items: []domain.CartItem{
+		{ID: 31, CartID: 3, ProductID: 10, Quantity: 2, Price: domain.NewMoney(10000, "RUB")},
+		{ID: 32, CartID: 3, ProductID: 11, Quantity: 98, Price: domain.NewMoney(5000, "RUB")},
+	}}
+
+	tests := []struct {
+		name          string
+		guestErr      error
+		userCoupon    string
+		addErr        error
+		expectMerge   bool
+		expectCoupon  bool
+		expectedError error
+	}{
+		{name: "Позиции гостя переносятся в корзину пользователя", expectMerge: true, expectCoupon: true},
+		{name: "Купон пользователя не заменяется купоном гостя", userCoupon: "VIP", expectMerge: true},
+		{name: "Гостевой корзины нет", guestErr: gorm.ErrRecordNotFound},
+		{name: "Ошибка переноса откатывает слияние", addErr: errors.New("db is down"), expectedError: errors.New("db is down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txCarts := new(MockCartRepository)
+			txCartItems := new(MockCartItemRepository)
+			uow := &fakeUnitOfWork{repos: repository.Repositories{Carts: txCarts, CartItems: txCartItems}}
+			mockCartRepo := new(MockCartRepository)
+			cartService := NewCartService(mockCartRepo, new(MockCartItemRepository), new(MockProductRepository), new(MockPromotionRepository), uow, newTestEngine())
+
+			if tt.guestErr != nil {
+				txCarts.On("GetByGuestTokenForUpdate", "guest-token").Return(nil, tt.guestErr)
+			} else {
+				txCarts.On("GetByGuestTokenForUpdate", "guest-token").Return(guestCart, nil)
+			}
+			txCarts.On("GetOrCreate", customer).Return(&domain.Cart{ID: 5, UserID: uintPtr(1), CouponCode: tt.userCoupon}, nil).Maybe()
+			// У пользователя уже есть 5 единиц товара 11: сумма превышает лимит позиции
+			txCartItems.On("AddQuantity", mock.MatchedBy(func(i *domain.CartItem) bool {
+				return i.CartID == 5 && i.ProductID == 10 && i.Quantity == 2 && i.Price == domain.NewMoney(10000, "RUB")
+			})).Return(tt.addErr).Run(func(args mock.Arguments) {
+				args.Get(0).(*domain.CartItem).ID = 51
+			}).Maybe()
+			txCartItems.On("AddQuantity", mock.MatchedBy(func(i *domain.CartItem) bool {
+				return i.CartID == 5 && i.ProductID == 11
+			})).Return(nil).Run(func(args mock.Arguments) {
+				item := args.Get(0).(*domain.CartItem)
+				item.ID = 52
+				item.Quantity += 5
+			}).Maybe()
+			txCartItems.On("Update", mock.MatchedBy(func(i *domain.CartItem) bool {
+				return i.ID == 52 && i.Quantity == domain.MaxLineQuantity
+			})).Return(nil).Maybe()
+			txCarts.On("SetCouponCode", uint(5), "SALE10").Return(nil).Maybe()
+			txCarts.On("Delete", uint(3)).Return(nil).Maybe()
+			mockCartRepo.On("GetOrCreate", customer).Return(&domain.Cart{ID: 5, UserID: uintPtr(1)}, nil).Maybe()
+
+			cart, err := cartService.MergeCart("guest-token", 1)
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+				assert.Nil(t, cart)
+				assert.True(t, uow.rolledBack)
+				txCarts.AssertNotCalled(t, "Delete", mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, uint(5), cart.ID)
+			assert.True(t, uow.committed)
+			if tt.expectMerge {
+				txCartItems.AssertNumberOfCalls(t, "AddQuantity", 2)
+				txCartItems.AssertNumberOfCalls(t, "Update", 1)
+				txCarts.AssertCalled(t, "Delete", uint(3))
+			} else {
+				txCartItems.AssertNotCalled(t, "AddQuantity", mock.Anything)
+				txCarts.AssertNotCalled(t, "Delete", mock.Anything)
+			}
+			if tt.expectCoupon {
+				txCarts.AssertCalled(t, "SetCouponCode", uint(5), "SALE10")
+			} else {
+				txCarts.AssertNotCalled(t, "SetCouponCode", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		currentStatus domain.OrderStatus
+		newStatus     domain.OrderStatus
+		expectedError error
+	}{
+		{name: "Оплата не трогает остатки", currentStatus: domain.OrderStatusPending, newStatus: domain.OrderStatusPaid},
+		{name: "Отмена без причины запрещена", currentStatus: domain.OrderStatusPending, newStatus: domain.OrderStatusCancelled, expectedError: domain.ErrValidation},
+		{name: "Нельзя отправить неоплаченный заказ", currentStatus: domain.OrderStatusPending, newStatus: domain.OrderStatusShipped, expectedError: domain.ErrConflict},
+		{name: "Нельзя собирать неоплаченный заказ", currentStatus: domain.OrderStatusPending, newStatus: domain.OrderStatusProcessing, expectedError: domain.ErrConflict},
+		{name: "Возврат только через заявку", currentStatus: domain.OrderStatusDelivered, newStatus: domain.OrderStatusRefunded, expectedError: domain.ErrConflict},
+		{name: "Возврат оплаченного заказа запрещен", currentStatus: domain.OrderStatusPaid, newStatus: domain.OrderStatusRefunded, expectedError: domain.ErrConflict},
+		{name: "Неизвестный статус", currentStatus: domain.OrderStatusPending, newStatus: "lost", expectedError: domain.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txOrders := new(MockOrderRepository)
+			txProducts := new(MockProductRepository)
+			txPayments := new(MockPaymentRepository)
+			uow := &fakeUnitOfWork{repos: repository.Repositories{Orders: txOrders, Products: txProducts, Payments: txPayments}}
+			orderService := NewOrderService(new(MockOrderRepository), new(MockCartRepository), new(MockCartItemRepository), new(MockProductRepository), new(MockAddressRepository), uow, newTestEngine(), nil)
+
+			txOrders.On("GetByIDForUpdate", uint(3)).Return(&domain.Order{
+				ID:     3,
+				Status: tt.currentStatus,
+				Items:  []domain.OrderItem{{ProductID: 10, Quantity: 2}, {ProductID: 11, Quantity: 1}},
+			}, nil)
+			if tt.expectedError == nil {
+				txOrders.On("UpdateStatus", uint(3), tt.newStatus).Return(nil)
+				txOrders.On("AddStatusHistory", mock.MatchedBy(func(h *domain.OrderStatusHistory) bool {
+					return h.OrderID == 3 && h.FromStatus == tt.currentStatus && h.ToStatus == tt.newStatus && *h.ChangedBy == 7
+				})).Return(nil)
+			}
+
+			err := orderService.UpdateOrderStatus(7, 3, tt.newStatus)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				txOrders.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				txOrders.AssertExpectations(t)
+			}
+			txProducts.AssertNotCalled(t, "IncrementStock", mock.Anything, mock.Anything)
+			txOrders.AssertNotCalled(t, "SetCancellation", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint
+		role           domain.Role
+		orderStatus    domain.OrderStatus
+		paidThroughAPI bool
+		refundFailure  payment.FailureMode
+		reason         string
+		notFound       bool
+		expectedError  error
+	}{
+		{name: "Покупатель отменяет новый заказ", userID: 1, role: domain.RoleCustomer, orderStatus: domain.OrderStatusPending, reason: "  передумал  "},
+		{name: "Отмена оплаченного заказа возвращает деньги", userID: 1, role: domain.RoleCustomer, orderStatus: domain.OrderStatusProcessing, paidThroughAPI: true, reason: "нашел дешевле"},
+		{name: "Отказ шлюза не отменяет отмену заказа", userID: 1, role: domain.RoleCustomer, orderStatus: domain.OrderStatusProcessing, paidThroughAPI: true, refundFailure: payment.FailureDecline, reason: "нашел дешевле", expectedError: domain.ErrPaymentDeclined},
+		{name: "Сотрудник отменяет чужой заказ", userID: 2, role: domain.RoleStaff, orderStatus: domain.OrderStatusProcessing, reason: "нет в наличии"},
+		{name: "Оплаченный заказ до сборки не отменяется", userID: 1, role: domain.RoleCustomer, orderStatus: domain.OrderStatusPaid, reason: "передумал", expectedError: service.ErrOrderNotCancellable},
+		{name: "Покупатель не может отменить чужой заказ", userID: 2, role: domain.RoleCustomer, orderStatus: domain.OrderStatusPending, reason: "передумал", expectedError: service.ErrForbidden},
+		{name: "Отправленный заказ не отменяется", userID: 1, role: domain.RoleCustomer, orderStatus: domain.OrderStatusShipped, reason: "передумал", expectedError: service.ErrOrderNotCancellable},
+		{name: "Доставленный заказ не отменяется", userID: 1, role: domain.RoleCustomer, orderStatus: domain.OrderStatusDelivered, reason: "передумал", expectedError: service.ErrOrderNotCancellable},
+		{name: "Повторная отмена", userID: 1, role: domain.RoleCustomer, orderStatus: domain.OrderStatusCancelled, reason: "передумал", expectedError: service.ErrOrderNotCancellable},
+		{name: "Без причины", userID: 1, role: domain.RoleCustomer, orderStatus: domain.OrderStatusPending, reason: "   ", expectedError: domain.ErrValidation},
+		{name: "Слишком длинная причина", userID: 1, role: domain.RoleCustomer, orderStatus: domain.OrderStatusPending, reason: strings.Repeat("я", maxCancellationReasonLength+1), expectedError: domain.ErrValidation},
+		{name: "Заказ не найден", userID: 1, role: domain.RoleCustomer, notFound: true, reason: "передумал", expectedError: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := payment.NewFakeGateway(nil)
+			payments := []domain.Payment{}
+			if tt.paidThroughAPI {
+				authorizationID, _ := gateway.Authorize(payment.AuthorizeRequest{OrderID: 3, Amount: domain.NewMoney(5000, "RUB"), Source: "tok_visa"})
+				transactionID, _ := gateway.Capture(authorizationID, domain.NewMoney(5000, "RUB"))
+				payments = append(payments, domain.Payment{ID: 4, OrderID: 3, Status: domain.PaymentStatusCaptured, Amount: domain.NewMoney(5000, "RUB"), TransactionID: transactionID})
+			}
+			gateway.SetFailure(payment.OperationRefund, tt.refundFailure)
+
+			mockOrderRepo := new(MockOrderRepository)
+			txOrders := new(MockOrderRepository)
+			txProducts := new(MockProductRepository)
+			txPayments := new(MockPaymentRepository)
+			txReturns := new(MockReturnRepository)
+			uow := &fakeUnitOfWork{repos: repository.Repositories{Orders: txOrders, Products: txProducts, Payments: txPayments, Returns: txReturns}}
+			orderService := NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockCartItemRepository), new(MockProductRepository), new(MockAddressRepository), uow, newTestEngine(), gateway)
+
+			if tt.notFound {
+				txOrders.On("GetByIDForUpdate", uint(3)).Return(nil, gorm.ErrRecordNotFound)
+			} else {
+				txOrders.On("GetByIDForUpdate", uint(3)).Return(&domain.Order{
+					ID:     3,
+					UserID: 1,
+					Status: tt.orderStatus,
+					Items:  []domain.OrderItem{{ProductID: 10, Quantity: 2}},
+				}, nil)
+			}
+			txProducts.On("IncrementStock", uint(10), 2).Return(nil).Maybe()
+			txOrders.On("UpdateStatus", uint(3), domain.OrderStatusCancelled).Return(nil).Maybe()
+			txOrders.On("SetCancellation", uint(3), strings.TrimSpace(tt.reason), mock.Anything).Return(nil).Maybe()
+			txOrders.On("AddStatusHistory", mock.MatchedBy(func(h *domain.OrderStatusHistory) bool {
+				return h.FromStatus == tt.orderStatus && h.ToStatus == domain.OrderStatusCancelled && *h.ChangedBy == tt.userID
+			})).Return(nil).Maybe()
+			txPayments.On("GetByOrderID", uint(3)).Return(payments, nil).Maybe()
+			txPayments.On("Update", mock.Anything).Return(nil).Maybe()
+			var refund *domain.Refund
+			var createdStatus domain.RefundStatus
+			txReturns.On("CreateRefund", mock.Anything).Run(func(args mock.Arguments) {
+				refund = args.Get(0).(*domain.Refund)
+				createdStatus = refund.Status
+			}).Return(nil).Maybe()
+			txReturns.On("UpdateRefund", mock.Anything).Return(nil).Maybe()
+			mockOrderRepo.On("GetByID", uint(3)).Return(&domain.Order{ID: 3, Status: domain.OrderStatusCancelled}, nil).Maybe()
+
+			order, err := orderService.CancelOrder(tt.userID, tt.role, 3, tt.reason)
+			if tt.refundFailure != payment.FailureNone {
+				// Шлюз вызывается после фиксации: заказ остается отмененным, возврат помечается failed
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.True(t, uow.committed)
+				txOrders.AssertCalled(t, "UpdateStatus", uint(3), domain.OrderStatusCancelled)
+				if assert.NotNil(t, refund) {
+					assert.Equal(t, domain.RefundStatusFailed, refund.Status)
+					assert.NotEmpty(t, refund.FailureReason)
+				}
+				assert.Equal(t, domain.PaymentStatusCaptured, payments[0].Status)
+				return
+			}
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				txOrders.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+				txProducts.AssertNotCalled(t, "IncrementStock", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, domain.OrderStatusCancelled, order.Status)
+			assert.True(t, uow.committed)
+			txProducts.AssertCalled(t, "IncrementStock", uint(10), 2)
+			txOrders.AssertCalled(t, "SetCancellation", uint(3), strings.TrimSpace(tt.reason), mock.Anything)
+			txOrders.AssertCalled(t, "AddStatusHistory", mock.Anything)
+			if tt.paidThroughAPI {
+				if assert.NotNil(t, refund) {
+					assert.Equal(t, domain.NewMoney(5000, "RUB"), refund.Amount)
+					assert.Equal(t, uint(4), *refund.PaymentID)
+					assert.Equal(t, domain.RefundStatusPending, createdStatus)
+					assert.Equal(t, domain.RefundStatusSucceeded, refund.Status)
+					assert.NotEmpty(t, refund.TransactionID)
+				}
+				assert.Equal(t, domain.PaymentStatusRefunded, payments[0].Status)
+			} else {
+				assert.Nil(t, refund)
+			}
+		})
+	}
+}
+
+func TestCreateOrderPriceChanged(t *testing.T) {
+	txCarts := new(MockCartRepository)
+	txCartItems := new(MockCartItemRepository)
+	txProducts := new(MockProductRepository)
+	txOrders := new(MockOrderRepository)
+	uow := &fakeUnitOfWork{repos: repository.Repositories{Carts: txCarts, CartItems: txCartItems, Products: txProducts, Orders: txOrders}}
+	mockAddressRepo := new(MockAddressRepository)
+	mockAddressRepo.On("GetByID", uint(1)).Return(&domain.Address{ID: 1, UserID: 1, PostalAddress: domain.PostalAddress{Country: "RU"}}, nil)
+	orderService := NewOrderService(new(MockOrderRepository), new(MockCartRepository), new(MockCartItemRepository), new(MockProductRepository), mockAddressRepo, uow, newTestEngine(), nil)
+
+	txCarts.On("GetByUserIDForUpdate", uint(1)).Return(&domain.Cart{ID: 5, UserID: uintPtr(1)}, nil)
+	txCartItems.On("GetByCartID", uint(5)).Return([]domain.CartItem{
+		{ID: 1, CartID: 5, ProductID: 10, Quantity: 2, Price: domain.NewMoney(10000, "RUB")},
+		{ID: 2, CartID: 5, ProductID: 11, Quantity: 1, Price: domain.NewMoney(4000, "RUB")},
+	}, nil)
+	txProducts.On("GetByID", uint(10)).Return(&domain.Product{ID: 10, Price: domain.NewMoney(10000, "RUB")}, nil)
+	txProducts.On("GetByID", uint(11)).Return(&domain.Product{ID: 11, Price: domain.NewMoney(5000, "RUB")}, nil)
+
+	order, err := orderService.CreateOrder(1, service.CheckoutRequest{ShippingAddressID: 1, ShippingMethod: "standard"})
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, domain.ErrPriceChanged)
+	var domainErr *domain.Error
+	if assert.ErrorAs(t, err, &domainErr) {
+		assert.Equal(t, []domain.PriceChange{
+			{ItemID: 2, ProductID: 11, OldPrice: domain.NewMoney(4000, "RUB"), NewPrice: domain.NewMoney(5000, "RUB")},
+		}, domainErr.Details["lines"])
+	}
+	assert.True(t, uow.rolledBack)
+	txProducts.AssertNotCalled(t, "DecrementStock", mock.Anything, mock.Anything)
+	txOrders.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestAcceptPriceChanges(t *testing.T) {
+	newPrice := domain.NewMoney(5000, "RUB")
+	tests := []struct {
+		name          string
+		owner         domain.CartOwner
+		accepted      []service.AcceptedPrice
+		productPrice  domain.Money
+		expectedError error
+	}{
+		{name: "Подтвержденная цена сохраняется", owner: customer, accepted: []service.AcceptedPrice{{ItemID: 2, NewPrice: newPrice}}, productPrice: newPrice},
+		{name: "Цена изменилась еще раз", owner: customer, accepted: []service.AcceptedPrice{{ItemID: 2, NewPrice: newPrice}}, productPrice: domain.NewMoney(6000, "RUB"), expectedError: domain.ErrPriceChanged},
+		{name: "Позиция из чужой корзины", owner: customer, accepted: []service.AcceptedPrice{{ItemID: 7, NewPrice: newPrice}}, productPrice: newPrice, expectedError: domain.ErrForbidden},
+		{name: "Пустой список цен", owner: customer, expectedError: domain.ErrValidation},
+		{name: "Корзины нет", owner: domain.CartOwner{UserID: 2}, accepted: []service.AcceptedPrice{{ItemID: 2, NewPrice: newPrice}}, expectedError: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			txCartItems := new(MockCartItemRepository)
+			txProducts := new(MockProductRepository)
+			uow := &fakeUnitOfWork{repos: repository.Repositories{CartItems: txCartItems, Products: txProducts}}
+			cartService := NewCartService(mockCartRepo, new(MockCartItemRepository), new(MockProductRepository), new(MockPromotionRepository), uow, newTestEngine())
+
+			mockCartRepo.On("GetByUserID", uint(1)).Return(&domain.Cart{ID: 5, UserID: uintPtr(1)}, nil)
+			mockCartRepo.On("GetByUserID", uint(2)).Return(nil, gorm.ErrRecordNotFound)
+			txCartItems.On("GetByID", uint(2)).Return(&domain.CartItem{ID: 2, CartID: 5, ProductID: 11, Quantity: 1, Price: domain.NewMoney(4000, "RUB")}, nil)
+			txCartItems.On("GetByID", uint(7)).Return(&domain.CartItem{ID: 7, CartID: 9, ProductID: 11, Quantity: 1, Price: domain.NewMoney(4000, "RUB")}, nil)
+			txProducts.On("GetByID", uint(11)).Return(&domain.Product{ID: 11, Price: tt.productPrice}, nil)
+			txCartItems.On("Update", mock.MatchedBy(func(i *domain.CartItem) bool {
+				return i.ID == 2 && i.Price == newPrice
+			})).Return(nil)
+			mockCartRepo.On("GetOrCreate", customer).Return(&domain.Cart{ID: 5, UserID: uintPtr(1), Items: []domain.CartItem{
+				{ID: 2, CartID: 5, ProductID: 11, Quantity: 1, Price: newPrice, Product: domain.Product{ID: 11, Price: newPrice}},
+			}}, nil)
+
+			cart, err := cartService.AcceptPriceChanges(tt.owner, tt.accepted)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, cart)
+				txCartItems.AssertNotCalled(t, "Update", mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, newPrice, cart.Summary.Total)
+			assert.True(t, uow.committed)
+			txCartItems.AssertNumberOfCalls(t, "Update", 1)
+		})
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	orderService := NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockCartItemRepository), new(MockProductRepository), new(MockAddressRepository), &fakeUnitOfWork{}, newTestEngine(), nil)
+
+	mockOrderRepo.On("GetByID", uint(10)).Return(&domain.Order{ID: 10, UserID: 1}, nil)
+
+	tests := []struct {
+		name          string
+		userID        uint
+		role          domain.Role
+		expectedError error
+	}{
+		{
+			name:   "Покупатель видит свой заказ",
+			userID: 1,
+			role:   domain.RoleCustomer,
+		},
+		{
+			name:          "Покупатель не видит чужой заказ",
+			userID:        2,
+			role:          domain.RoleCustomer,
+			expectedError: service.ErrForbidden,
+		},
+		{
+			name:   "Сотрудник видит любой заказ",
+			userID: 3,
+			role:   domain.RoleStaff,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := orderService.GetOrder(tt.userID, tt.role, 10)
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, uint(10), order.ID)
+		})
+	}
+}
+
+func TestCreateOrder(t *testing.T) {
+	errInsert := errors.New("insert failed")
+
+	tests := []struct {
+		name             string
+		checkout         service.CheckoutRequest
+		setupMocks       func(tx repository.Repositories)
+		expectedError    error
+		expectCommit     bool
+		expectedTotal    domain.Money
+		expectedRollback bool
+	}{
+		{
+			name: "Успешное оформление заказа",
+			setupMocks: func(tx repository.Repositories) {
+				tx.Carts.(*MockCartRepository).On("GetByUserIDForUpdate", uint(1)).Return(&domain.Cart{ID: 5, UserID: uintPtr(1)}, nil)
+				tx.CartItems.(*MockCartItemRepository).On("GetByCartID", uint(5)).Return([]domain.CartItem{
+					{ID: 1, CartID: 5, ProductID: 10, Quantity: 2, Price: domain.NewMoney(10000, "RUB")},
+					{ID: 2, CartID: 5, ProductID: 11, Quantity: 1, Price: domain.NewMoney(5000, "RUB")},
+				}, nil)
+				tx.Products.(*MockProductRepository).On("GetByID", uint(10)).Return(&domain.Product{ID: 10, Price: domain.NewMoney(10000, "RUB")}, nil)
+				tx.Products.(*MockProductRepository).On("GetByID", uint(11)).Return(&domain.Product{ID: 11, Price: domain.NewMoney(5000, "RUB")}, nil)
+				tx.Products.(*MockProductRepository).On("DecrementStock", uint(10), 2).Return(true, nil)
+				tx.Products.(*MockProductRepository).On("DecrementStock", uint(11), 1).Return(true, nil)
+				tx.Orders.(*MockOrderRepository).On("Create", mock.MatchedBy(func(o *domain.Order) bool {
+					return o.Total == domain.NewMoney(25000, "RUB") &&
+						o.ShippingMethod == "standard" &&
+						o.ShippingAddress.City == "Москва" &&
+						o.BillingAddress == o.ShippingAddress
+				})).Run(func(args mock.Arguments) {
+					args.Get(0).(*domain.Order).ID = 42
+				}).Return(nil)
+				tx.Orders.(*MockOrderRepository).On("AddStatusHistory", mock.MatchedBy(func(h *domain.OrderStatusHistory) bool {
+					return h.OrderID == 42 && h.ToStatus == domain.OrderStatusPending && *h.ChangedBy == 1
+				})).Return(nil)
+				tx.Orders.(*MockOrderRepository).On("CreateOrderItem", mock.MatchedBy(func(i *domain.OrderItem) bool {
+					return i.OrderID == 42
+				})).Return(nil).Twice()
+				tx.Carts.(*MockCartRepository).On("Delete", uint(5)).Return(nil)
+			},
+			expectCommit:  true,
+			expectedTotal: domain.NewMoney(25000, "RUB"),
+		},
+		{
+			name: "Откат при ошибке вставки позиции заказа",
+			setupMocks: func(tx repository.Repositories) {
+				tx.Carts.(*MockCartRepository).On("GetByUserIDForUpdate", uint(1)).Return(&domain.Cart{ID: 5, UserID: uintPtr(1)}, nil)
+				tx.CartItems.(*MockCartItemRepository).On("GetByCartID", uint(5)).Return([]domain.CartItem{
+					{ID: 1, CartID: 5, ProductID: 10, Quantity: 2, Price: domain.NewMoney(10000, "RUB")},
+					{ID: 2, CartID: 5, ProductID: 11, Quantity: 1, Price: domain.NewMoney(5000, "RUB")},
+				}, nil)
+				tx.Products.(*MockProductRepository).On("GetByID", uint(10)).Return(&domain.Product{ID: 10, Price: domain.NewMoney(10000, "RUB")}, nil)
+				tx.Products.(*MockProductRepository).On("GetByID", uint(11)).Return(&domain.Product{ID: 11, Price: domain.NewMoney(5000, "RUB")}, nil)
+				tx.Products.(*MockProductRepository).On("DecrementStock", mock.Anything, mock.Anything).Return(true, nil)
+				tx.Orders.(*MockOrderRepository).On("Create", mock.AnythingOfType("*domain.Order")).Return(nil)
+				tx.Orders.(*MockOrderRepository).On("AddStatusHistory", mock.AnythingOfType("*domain.OrderStatusHistory")).Return(nil)
+				tx.Orders.(*MockOrderRepository).On("CreateOrderItem", mock.MatchedBy(func(i *domain.OrderItem) bool {
+					return i.ProductID == 10
+				})).Return(nil).Once()
+				tx.Orders.(*MockOrderRepository).On("CreateOrderItem", mock.MatchedBy(func(i *domain.OrderItem) bool {
+					return i.ProductID == 11
+				})).Return(errInsert).Once()
+			},
+			expectedError:    errInsert,
+			expectedRollback: true,
+		},
+		{
+			name: "Нехватка товара по нескольким позициям",
+			setupMocks: func(tx repository.Repositories) {
+				tx.Carts.(*MockCartRepository).On("GetByUserIDForUpdate", uint(1)).Return(&domain.Cart{ID: 5, UserID: uintPtr(1)}, nil)
+				tx.CartItems.(*MockCartItemRepository).On("GetByCartID", uint(5)).Return([]domain.CartItem{
+					{ID: 1, CartID: 5, ProductID: 10, Quantity: 2, Price: domain.NewMoney(10000, "RUB")},
+					{ID: 2, CartID: 5, ProductID: 11, Quantity: 3, Price: domain.NewMoney(5000, "RUB")},
+					{ID: 3, CartID: 5, ProductID: 12, Quantity: 1, Price: domain.NewMoney(100, "RUB")},
+				}, nil)
+				tx.Products.(*MockProductRepository).On("GetByID", uint(10)).Return(&domain.Product{ID: 10, Price: domain.NewMoney(10000, "RUB"), Stock: 1}, nil)
+				tx.Products.(*MockProductRepository).On("GetByID", uint(11)).Return(&domain.Product{ID: 11, Price: domain.NewMoney(5000, "RUB"), Stock: 0}, nil)
+				tx.Products.(*MockProductRepository).On("GetByID", uint(12)).Return(&domain.Product{ID: 12, Price: domain.NewMoney(100, "RUB"), Stock: 5}, nil)
+				tx.Products.(*MockProductRepository).On("DecrementStock", uint(10), 2).Return(false, nil)
+				tx.Products.(*MockProductRepository).On("DecrementStock", uint(11), 3).Return(false, nil)
+				tx.Products.(*MockProductRepository).On("DecrementStock", uint(12), 1).Return(true, nil)
+			},
+			expectedError:    domain.ErrInsufficientStock,
+			expectedRollback: true,
+		},
+		{
+			name: "Пустая корзина",
+			setupMocks: func(tx repository.Repositories) {
+				tx.Carts.(*MockCartRepository).On("GetByUserIDForUpdate", uint(1)).Return(&domain.Cart{ID: 5, UserID: uintPtr(1)}, nil)
+				tx.CartItems.(*MockCartItemRepository).On("GetByCartID", uint(5)).Return([]domain.CartItem{}, nil)
+			},
+			expectedError:    domain.ErrEmptyCart,
+			expectedRollback: true,
+		},
+		{
+			name:          "Чужой адрес доставки",
+			checkout:      service.CheckoutRequest{ShippingAddressID: 2, ShippingMethod: "standard"},
+			setupMocks:    func(tx repository.Repositories) {},
+			expectedError: domain.ErrNotFound,
+		},
+		{
+			name:          "Способ доставки не указан",
+			checkout:      service.CheckoutRequest{ShippingAddressID: 1},
+			setupMocks:    func(tx repository.Repositories) {},
+			expectedError: domain.ErrValidation,
+		},
+		{
+			name: "Скидка по купону сохраняется в заказе",
+			setupMocks: func(tx repository.Repositories) {
+				tx.Carts.(*MockCartRepository).On("GetByUserIDForUpdate", uint(1)).Return(&domain.Cart{ID: 5, UserID: uintPtr(1), CouponCode: "SALE10"}, nil)
+				tx.CartItems.(*MockCartItemRepository).On("GetByCartID", uint(5)).Return([]domain.CartItem{
+					{ID: 1, CartID: 5, ProductID: 10, Quantity: 2, Price: domain.NewMoney(10000, "RUB")},
+				}, nil)
+				tx.Promotions.(*MockPromotionRepository).On("GetByCode", "SALE10").Return(&domain.Promotion{
+					ID: 3, Code: "SALE10", Type: domain.PromotionPercentage, PercentOff: 10,
+				}, nil)
+				tx.Products.(*MockProductRepository).On("GetByID", uint(10)).Return(&domain.Product{ID: 10, Price: domain.NewMoney(10000, "RUB")}, nil)
+				tx.Products.(*MockProductRepository).On("DecrementStock", uint(10), 2).Return(true, nil)
+				tx.Orders.(*MockOrderRepository).On("Create", mock.MatchedBy(func(o *domain.Order) bool {
+					return o.Subtotal == domain.NewMoney(20000, "RUB") && o.Discount == domain.NewMoney(2000, "RUB") && o.Total == domain.NewMoney(18000, "RUB")
+				})).Run(func(args mock.Arguments) {
+					args.Get(0).(*domain.Order).ID = 42
+				}).Return(nil)
+				tx.Orders.(*MockOrderRepository).On("AddStatusHistory", mock.AnythingOfType("*domain.OrderStatusHistory")).Return(nil)
+				tx.Orders.(*MockOrderRepository).On("CreateOrderItem", mock.AnythingOfType("*domain.OrderItem")).Return(nil)
+				tx.Orders.(*MockOrderRepository).On("CreateOrderDiscount", mock.MatchedBy(func(d *domain.OrderDiscount) bool {
+					return d.OrderID == 42 && d.Code == "SALE10" && d.Amount == domain.NewMoney(2000, "RUB")
+				})).Return(nil)
+				tx.Promotions.(*MockPromotionRepository).On("Redeem", mock.MatchedBy(func(r *domain.PromotionRedemption) bool {
+					return r.PromotionID == 3 && r.UserID == 1 && r.OrderID == 42
+				})).Return(true, nil)
+				tx.Carts.(*MockCartRepository).On("Delete", uint(5)).Return(nil)
+			},
+			expectCommit:  true,
+			expectedTotal: domain.NewMoney(18000, "RUB"),
+		},
+		{
+			name: "Откат, если лимит купона исчерпан параллельным заказом",
+			setupMocks: func(tx repository.Repositories) {
+				tx.Carts.(*MockCartRepository).On("GetByUserIDForUpdate", uint(1)).Return(&domain.Cart{ID: 5, UserID: uintPtr(1), CouponCode: "SALE10"}, nil)
+				tx.CartItems.(*MockCartItemRepository).On("GetByCartID", uint(5)).Return([]domain.CartItem{
+					{ID: 1, CartID: 5, ProductID: 10, Quantity: 2, Price: domain.NewMoney(10000, "RUB")},
+				}, nil)
+				tx.Promotions.(*MockPromotionRepository).On("GetByCode", "SALE10").Return(&domain.Promotion{
+					ID: 3, Code: "SALE10", Type: domain.PromotionPercentage, PercentOff: 10, UsageLimit: 1,
+				}, nil)
+				tx.Products.(*MockProductRepository).On("GetByID", uint(10)).Return(&domain.Product{ID: 10, Price: domain.NewMoney(10000, "RUB")}, nil)
+				tx.Products.(*MockProductRepository).On("DecrementStock", uint(10), 2).Return(true, nil)
+				tx.Orders.(*MockOrderRepository).On("Create", mock.AnythingOfType("*domain.Order")).Return(nil)
+				tx.Orders.(*MockOrderRepository).On("AddStatusHistory", mock.AnythingOfType("*domain.OrderStatusHistory")).Return(nil)
+				tx.Orders.(*MockOrderRepository).On("CreateOrderItem", mock.AnythingOfType("*domain.OrderItem")).Return(nil)
+				tx.Orders.(*MockOrderRepository).On("CreateOrderDiscount", mock.AnythingOfType("*domain.OrderDiscount")).Return(nil)
+				tx.Promotions.(*MockPromotionRepository).On("Redeem", mock.AnythingOfType("*domain.PromotionRedemption")).Return(false, nil)
+			},
+			expectedError:    service.ErrCouponLimitReached,
+			expectedRollback: true,
+		},
+		{
+			name: "Корзина уже оформлена параллельным запросом",
+			setupMocks: func(tx repository.Repositories) {
+				tx.Carts.(*MockCartRepository).On("GetByUserIDForUpdate", uint(1)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedError:    domain.ErrEmptyCart,
+			expectedRollback: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := repository.Repositories{
+				Carts:      new(MockCartRepository),
+				CartItems:  new(MockCartItemRepository),
+				Orders:     new(MockOrderRepository),
+				Products:   new(MockProductRepository),
+				Promotions: new(MockPromotionRepository),
+			}
+			tt.setupMocks(tx)
+			uow := &fakeUnitOfWork{repos: tx}
+
+			// Репозитории вне транзакции используются только для чтения готового заказа
+			mockOrderRepo := new(MockOrderRepository)
+			if tt.expectCommit {
+				mockOrderRepo.On("GetByID", uint(42)).Return(&domain.Order{ID: 42, Total: tt.expectedTotal}, nil)
+			}
+			mockAddressRepo := new(MockAddressRepository)
+			mockAddressRepo.On("GetByID", uint(1)).Return(&domain.Address{ID: 1, UserID: 1, PostalAddress: domain.PostalAddress{City: "Москва", Country: "RU"}}, nil).Maybe()
+			mockAddressRepo.On("GetByID", uint(2)).Return(&domain.Address{ID: 2, UserID: 2, PostalAddress: domain.PostalAddress{City: "Минск", Country: "BY"}}, nil).Maybe()
+			orderService := NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockCartItemRepository), new(MockProductRepository), mockAddressRepo, uow, newTestEngine(), nil)
+
+			checkout := tt.checkout
+			if checkout == (service.CheckoutRequest{}) {
+				checkout = service.CheckoutRequest{ShippingAddressID: 1, ShippingMethod: "standard"}
+			}
+			order, err := orderService.CreateOrder(1, checkout)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, order)
+				assert.Equal(t, tt.expectedRollback, uow.rolledBack)
+				assert.False(t, uow.committed)
+				tx.Carts.(*MockCartRepository).AssertNotCalled(t, "Delete", mock.Anything)
+				mockOrderRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, uow.committed)
+			assert.Equal(t, tt.expectedTotal, order.Total)
+			tx.Orders.(*MockOrderRepository).AssertExpectations(t)
+			tx.Carts.(*MockCartRepository).AssertExpectations(t)
+		})
+	}
+}
+
 // Тесты для ProductService
 func TestCreateProduct(t *testing.T) {
 	mockProductRepo := new(MockProductRepository)
-	service := NewProductService(mockProductRepo)
+	service := NewProductService(mockProductRepo, zeroTaxes)
 
 	tests := []struct {
 		name          string
@@ -174,13 +1279,33 @@ func TestCreateProduct(t *testing.T) {
 			product: &domain.Product{
 				Name:        "Test Product",
 				Description: "Test Description",
-				Price:       100,
+				Price:       domain.NewMoney(10000, "RUB"),
 			},
 			setupMocks: func() {
 				mockProductRepo.On("Create", mock.AnythingOfType("*domain.Product")).Return(nil)
 			},
 			expectedError: nil,
 		},
+		{
+			name: "Отрицательный вес",
+			product: &domain.Product{
+				Name:        "Test Product",
+				Price:       domain.NewMoney(10000, "RUB"),
+				WeightGrams: -1,
+			},
+			setupMocks:    func() {},
+			expectedError: domain.ErrValidation,
+		},
+		{
+			name: "Неизвестная налоговая категория",
+			product: &domain.Product{
+				Name:        "Test Product",
+				Price:       domain.NewMoney(10000, "RUB"),
+				TaxCategory: "luxury",
+			},
+			setupMocks:    func() {},
+			expectedError: domain.ErrValidation,
+		},
 	}
 
 	for _, tt := range tests {
@@ -188,12 +1313,112 @@ func TestCreateProduct(t *testing.T) {
 			tt.setupMocks()
 			err := service.CreateProduct(tt.product)
 			if tt.expectedError != nil {
-				assert.Error(t, err)
-				assert.Equal(t, tt.expectedError, err)
+				assert.ErrorIs(t, err, tt.expectedError)
 				return
 			}
+			assert.Equal(t, tax.DefaultCategory, tt.product.TaxCategory)
 			assert.NoError(t, err)
 			mockProductRepo.AssertExpectations(t)
 		})
 	}
 }
+
+func TestUpdateProduct(t *testing.T) {
+	mockProductRepo := new(MockProductRepository)
+	service := NewProductService(mockProductRepo, zeroTaxes)
+
+	mockProductRepo.On("GetByID", uint(1)).Return(&domain.Product{ID: 1, Name: "Old", Price: domain.NewMoney(5000, "RUB")}, nil)
+	mockProductRepo.On("GetByID", uint(2)).Return(nil, gorm.ErrRecordNotFound)
+	mockProductRepo.On("Update", mock.MatchedBy(func(p *domain.Product) bool {
+		return p.ID == 1 && p.Name == "New" && p.Price == domain.NewMoney(7500, "RUB") && p.WeightGrams == 1200
+	})).Return(nil)
+
+	product := &domain.Product{ID: 1, Name: "New", Price: domain.NewMoney(7500, "RUB"), WeightGrams: 1200}
+	assert.NoError(t, service.UpdateProduct(product))
+	assert.Equal(t, "New", product.Name)
+	assert.Equal(t, 1200, product.WeightGrams)
+
+	err := service.UpdateProduct(&domain.Product{ID: 1, Name: "New", WeightGrams: -5})
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	err = service.UpdateProduct(&domain.Product{ID: 2, Name: "Missing"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	mockProductRepo.AssertExpectations(t)
+}
+
+func TestListProducts(t *testing.T) {
+	products := []domain.Product{
+		{ID: 1, Name: "A", Price: domain.NewMoney(1000, "RUB")},
+		{ID: 2, Name: "B", Price: domain.NewMoney(2000, "RUB")},
+		{ID: 3, Name: "C", Price: domain.NewMoney(2000, "RUB")},
+	}
+	filter := domain.ProductFilter{Category: "kitchen", InStock: true}
+
+	tests := []struct {
+		name          string
+		query         domain.ProductQuery
+		found         []domain.Product
+		expectedItems int
+		expectedNext  *domain.ProductCursor
+		expectedError error
+	}{
+		{
+			name:          "Есть следующая страница",
+			query:         domain.ProductQuery{ProductFilter: filter, Sort: domain.ProductSortPriceAsc, Limit: 2},
+			found:         products,
+			expectedItems: 2,
+			expectedNext:  &domain.ProductCursor{Sort: domain.ProductSortPriceAsc, ID: 2, Price: 2000},
+		},
+		{
+			name:          "Последняя страница",
+			query:         domain.ProductQuery{ProductFilter: filter, Sort: domain.ProductSortPriceAsc, Limit: 3},
+			found:         products,
+			expectedItems: 3,
+		},
+		{
+			name:          "Пустая выборка",
+			query:         domain.ProductQuery{ProductFilter: filter},
+			expectedItems: 0,
+		},
+		{
+			name:          "Неверный запрос",
+			query:         domain.ProductQuery{Limit: -1},
+			expectedError: domain.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProductRepo := new(MockProductRepository)
+			service := NewProductService(mockProductRepo, zeroTaxes)
+
+			limit := tt.query.Limit
+			if limit == 0 {
+				limit = domain.DefaultProductPageSize
+			}
+			// Репозиторий запрашивается на один товар больше страницы
+			mockProductRepo.On("List", mock.MatchedBy(func(q domain.ProductQuery) bool {
+				return q.ProductFilter == filter && q.Limit == limit+1
+			})).Return(tt.found, nil).Maybe()
+			mockProductRepo.On("Count", filter).Return(int64(len(tt.found)), nil).Maybe()
+
+			page, err := service.ListProducts(tt.query)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, page)
+				mockProductRepo.AssertNotCalled(t, "List", mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, page.Items, tt.expectedItems)
+			assert.NotNil(t, page.Items)
+			assert.Equal(t, int64(len(tt.found)), page.Total)
+			if tt.expectedNext != nil {
+				assert.Equal(t, tt.expectedNext.Encode(), page.NextCursor)
+			} else {
+				assert.Empty(t, page.NextCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package impl
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+)
+
+// checkStock проверяет, что товара на складе хватает на quantity единиц
+// Проверка предварительная: окончательно остаток списывается при оформлении заказа
+func checkStock(product *domain.Product, quantity int) error {
+	if quantity > product.Stock {
+		return domain.NewInsufficientStockError(domain.StockShortage{
+			ProductID: product.ID,
+			Requested: quantity,
+			Available: product.Stock,
+		})
+	}
+	return nil
+}
+
+// reserveStock списывает остатки по всем позициям корзины
+// Должна вызываться внутри транзакции: при нехватке хотя бы одной позиции
+// возвращается ошибка со всеми недостающими позициями, и списания откатываются
+func reserveStock(products repository.ProductRepository, items []domain.CartItem) error {
+	var shortages []domain.StockShortage
+	for _, item := range items {
+		ok, err := products.DecrementStock(item.ProductID, item.Quantity)
+		if err != nil {
+			return err
+		}
+		if ok {
+			continue
+		}
+
+		available := 0
+		if product, err := products.GetByID(item.ProductID); err == nil {
+			available = product.Stock
+		}
+		shortages = append(shortages, domain.StockShortage{
+			ProductID: item.ProductID,
+			Requested: item.Quantity,
+			Available: available,
+		})
+	}
+
+	if len(shortages) > 0 {
+		return domain.NewInsufficientStockError(shortages...)
+	}
+	return nil
+}
+
+// releaseStock возвращает на склад товары из позиций заказа
+func releaseStock(products repository.ProductRepository, items []domain.OrderItem) error {
+	for _, item := range items {
+		if err := products.IncrementStock(item.ProductID, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
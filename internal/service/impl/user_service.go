@@ -0,0 +1,134 @@
+package impl
+
+import (
+	"errors"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// userService реализует интерфейс UserService
+type userService struct {
+	userRepo repository.UserRepository
+}
+
+// NewUserService создает новый экземпляр UserService
+func NewUserService(userRepo repository.UserRepository) service.UserService {
+	return &userService{
+		userRepo: userRepo,
+	}
+}
+
+// Register регистрирует нового пользователя
+// Пароль сохраняется только в виде bcrypt-хеша
+func (s *userService) Register(email, password, name string) (*domain.User, error) {
+	email = normalizeEmail(email)
+
+	if _, err := s.userRepo.GetByEmail(email); err == nil {
+		return nil, service.ErrEmailTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	hash, err := passwordHash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &domain.User{
+		Email:        email,
+		Name:         name,
+		Role:         domain.RoleCustomer,
+		PasswordHash: hash,
+	}
+
+	if err := s.userRepo.Create(user); err != nil {
+		// Email мог занять параллельный запрос между проверкой и вставкой
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, service.ErrEmailTaken
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// Login проверяет email и пароль и возвращает пользователя
+func (s *userService) Login(email, password string) (*domain.User, error) {
+	user, err := s.userRepo.GetByEmail(normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, service.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, service.ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// ChangePassword меняет пароль пользователя после проверки текущего
+func (s *userService) ChangePassword(userID uint, oldPassword, newPassword string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return wrapNotFound(err, "user")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(oldPassword)); err != nil {
+		return service.ErrInvalidCredentials
+	}
+
+	hash, err := passwordHash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = hash
+	return s.userRepo.Update(user)
+}
+
+// passwordHash возвращает bcrypt-хеш пароля
+// bcrypt не принимает пароли длиннее 72 байт, такой пароль - ошибка валидации
+func passwordHash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return "", domain.NewValidationError("password must not exceed 72 bytes")
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// GetUser возвращает пользователя по его ID
+func (s *userService) GetUser(id uint) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, wrapNotFound(err, "user")
+	}
+	return user, nil
+}
+
+// SetRole назначает пользователю роль
+func (s *userService) SetRole(userID uint, role domain.Role) error {
+	if !role.IsValid() {
+		return service.ErrInvalidRole
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return wrapNotFound(err, "user")
+	}
+
+	user.Role = role
+	return s.userRepo.Update(user)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,190 @@
+package impl
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// MockUserRepository - мок репозитория пользователей
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(user *domain.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByID(id uint) (*domain.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(email string) (*domain.User, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(user *domain.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func hashPassword(t *testing.T, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.NoError(t, err)
+	return string(hash)
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name          string
+		email         string
+		password      string
+		setupMocks    func(repo *MockUserRepository)
+		expectedError error
+	}{
+		{
+			name:  "Успешная регистрация",
+			email: " New@Example.com ",
+			setupMocks: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", "new@example.com").Return(nil, gorm.ErrRecordNotFound)
+				repo.On("Create", mock.MatchedBy(func(user *domain.User) bool {
+					return user.Email == "new@example.com" &&
+						bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("password123")) == nil
+				})).Return(nil)
+			},
+		},
+		{
+			name:  "Email уже занят",
+			email: "taken@example.com",
+			setupMocks: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", "taken@example.com").Return(&domain.User{ID: 1}, nil)
+			},
+			expectedError: service.ErrEmailTaken,
+		},
+		{
+			name:  "Email занят параллельной регистрацией",
+			email: "race@example.com",
+			setupMocks: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", "race@example.com").Return(nil, gorm.ErrRecordNotFound)
+				repo.On("Create", mock.AnythingOfType("*domain.User")).Return(gorm.ErrDuplicatedKey)
+			},
+			expectedError: service.ErrEmailTaken,
+		},
+		{
+			// bcrypt не принимает пароли длиннее 72 байт: 40 кириллических символов - 80 байт
+			name:     "Слишком длинный пароль",
+			email:    "long@example.com",
+			password: strings.Repeat("п", 40),
+			setupMocks: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", "long@example.com").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedError: domain.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := new(MockUserRepository)
+			tt.setupMocks(mockUserRepo)
+			userService := NewUserService(mockUserRepo)
+
+			password := tt.password
+			if password == "" {
+				password = "password123"
+			}
+			user, err := userService.Register(tt.email, password, "Test")
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "new@example.com", user.Email)
+			mockUserRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	stored := &domain.User{ID: 7, Email: "user@example.com", PasswordHash: hashPassword(t, "password123")}
+
+	tests := []struct {
+		name          string
+		email         string
+		password      string
+		setupMocks    func(repo *MockUserRepository)
+		expectedError error
+	}{
+		{
+			name:     "Успешный вход",
+			email:    "user@example.com",
+			password: "password123",
+			setupMocks: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", "user@example.com").Return(stored, nil)
+			},
+		},
+		{
+			name:     "Неверный пароль",
+			email:    "user@example.com",
+			password: "wrong-password",
+			setupMocks: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", "user@example.com").Return(stored, nil)
+			},
+			expectedError: service.ErrInvalidCredentials,
+		},
+		{
+			name:     "Неизвестный email",
+			email:    "nobody@example.com",
+			password: "password123",
+			setupMocks: func(repo *MockUserRepository) {
+				repo.On("GetByEmail", "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedError: service.ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := new(MockUserRepository)
+			tt.setupMocks(mockUserRepo)
+			userService := NewUserService(mockUserRepo)
+
+			user, err := userService.Login(tt.email, tt.password)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, stored.ID, user.ID)
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	userService := NewUserService(mockUserRepo)
+
+	mockUserRepo.On("GetByID", uint(7)).Return(&domain.User{ID: 7, PasswordHash: hashPassword(t, "old-password")}, nil)
+	mockUserRepo.On("Update", mock.MatchedBy(func(user *domain.User) bool {
+		return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("new-password")) == nil
+	})).Return(nil)
+
+	assert.ErrorIs(t, userService.ChangePassword(7, "wrong", "new-password"), service.ErrInvalidCredentials)
+	assert.ErrorIs(t, userService.ChangePassword(7, "old-password", strings.Repeat("x", 73)), domain.ErrValidation)
+	assert.NoError(t, userService.ChangePassword(7, "old-password", "new-password"))
+	mockUserRepo.AssertExpectations(t)
+}
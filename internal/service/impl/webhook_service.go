@@ -0,0 +1,110 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+)
+
+// orderEventStatuses - статусы заказа, в которые его переводят события платежных
+// провайдеров и служб доставки. Возвратные статусы событиями не устанавливаются:
+// возврат проходит через одобрение заявки вместе с возвратом товаров и денег
+var orderEventStatuses = map[string]domain.OrderStatus{
+	"payment.succeeded":      domain.OrderStatusPaid,
+	"fulfillment.processing": domain.OrderStatusProcessing,
+	"fulfillment.shipped":    domain.OrderStatusShipped,
+	"fulfillment.delivered":  domain.OrderStatusDelivered,
+	"order.cancelled":        domain.OrderStatusCancelled,
+}
+
+// maxEventIDLength - наибольшая длина идентификатора события, см. domain.WebhookEvent.EventID
+const maxEventIDLength = 128
+
+// webhookCancellationReason - причина отмены заказа по событию order.cancelled
+const webhookCancellationReason = "заказ отменен внешней системой (order.cancelled)"
+
+// webhookService реализует интерфейс WebhookService
+type webhookService struct {
+	webhookRepo  repository.WebhookEventRepository
+	orderRepo    repository.OrderRepository
+	orderService service.OrderService
+}
+
+// NewWebhookService создает новый экземпляр WebhookService
+func NewWebhookService(webhookRepo repository.WebhookEventRepository, orderRepo repository.OrderRepository, orderService service.OrderService) service.WebhookService {
+	return &webhookService{
+		webhookRepo:  webhookRepo,
+		orderRepo:    orderRepo,
+		orderService: orderService,
+	}
+}
+
+// HandleOrderEvent переводит заказ в статус, соответствующий событию
+// Событие сначала закрепляется за текущим запросом. При временной ошибке оно освобождается,
+// чтобы отправитель мог доставить его повторно; если же событие неприменимо к заказу
+// (заказ не найден, переход недопустим), оно остается сохраненным, и повторная доставка
+// отвечает как дубликат. Изменение записывается в журнал как системное
+func (s *webhookService) HandleOrderEvent(event service.OrderEvent) (bool, error) {
+	if event.ID == "" || event.OrderID == 0 {
+		return false, domain.NewValidationError("event id and order id are required")
+	}
+	if len(event.ID) > maxEventIDLength {
+		return false, domain.NewValidationError(fmt.Sprintf("event id must not exceed %d characters", maxEventIDLength))
+	}
+	status, ok := orderEventStatuses[event.Type]
+	if !ok {
+		return false, domain.NewValidationError(fmt.Sprintf("unknown event type %q", event.Type))
+	}
+
+	claimed, err := s.webhookRepo.Claim(&domain.WebhookEvent{
+		EventID: event.ID,
+		Type:    event.Type,
+		OrderID: event.OrderID,
+	})
+	if err != nil {
+		return false, err
+	}
+	if !claimed {
+		return false, nil
+	}
+
+	if err := s.applyStatus(event.OrderID, status); err != nil {
+		if isTerminalEventError(err) {
+			return false, err
+		}
+		if releaseErr := s.webhookRepo.Release(event.ID); releaseErr != nil {
+			return false, errors.Join(err, releaseErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// applyStatus переводит заказ в статус status
+// Заказ, уже находящийся в этом статусе, не меняется: то же изменение могло прийти
+// другим путем, например через оплату в API. Отмена выполняется через CancelOrder
+// от имени системы с правами сотрудника и системной причиной
+func (s *webhookService) applyStatus(orderID uint, status domain.OrderStatus) error {
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return wrapNotFound(err, "order")
+	}
+	if order.Status == status {
+		return nil
+	}
+	if status == domain.OrderStatusCancelled {
+		_, err := s.orderService.CancelOrder(0, domain.RoleStaff, orderID, webhookCancellationReason)
+		return err
+	}
+	return s.orderService.UpdateOrderStatus(0, orderID, status)
+}
+
+// isTerminalEventError сообщает, что событие не будет применено и при повторной доставке:
+// заказа нет, переход недопустим или событие противоречит данным заказа
+func isTerminalEventError(err error) bool {
+	return errors.Is(err, domain.ErrNotFound) ||
+		errors.Is(err, domain.ErrConflict) ||
+		errors.Is(err, domain.ErrValidation)
+}
@@ -0,0 +1,218 @@
+package impl
+
+import (
+	"errors"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockWebhookEventRepository - мок репозитория событий вебхуков
+type MockWebhookEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookEventRepository) Claim(event *domain.WebhookEvent) (bool, error) {
+	args := m.Called(event)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWebhookEventRepository) Release(eventID string) error {
+	args := m.Called(eventID)
+	return args.Error(0)
+}
+
+func TestHandleOrderEvent(t *testing.T) {
+	tests := []struct {
+		name            string
+		event           service.OrderEvent
+		claimed         bool
+		currentStatus   domain.OrderStatus
+		expectUpdate    bool
+		expectRelease   bool
+		expectProcessed bool
+		expectedError   error
+	}{
+		{
+			name:            "Оплата переводит заказ в paid",
+			event:           service.OrderEvent{ID: "evt_1", Type: "payment.succeeded", OrderID: 3},
+			claimed:         true,
+			currentStatus:   domain.OrderStatusPending,
+			expectUpdate:    true,
+			expectProcessed: true,
+		},
+		{
+			name:            "Отмена сохраняет системную причину",
+			event:           service.OrderEvent{ID: "evt_5", Type: "order.cancelled", OrderID: 3},
+			claimed:         true,
+			currentStatus:   domain.OrderStatusProcessing,
+			expectUpdate:    true,
+			expectProcessed: true,
+		},
+		{
+			name:          "Отправленный заказ не отменяется",
+			event:         service.OrderEvent{ID: "evt_6", Type: "order.cancelled", OrderID: 3},
+			claimed:       true,
+			currentStatus: domain.OrderStatusShipped,
+			expectUpdate:  true,
+			expectedError: service.ErrOrderNotCancellable,
+		},
+		{
+			name:    "Повторное событие игнорируется",
+			event:   service.OrderEvent{ID: "evt_1", Type: "payment.succeeded", OrderID: 3},
+			claimed: false,
+		},
+		{
+			name:            "Заказ уже в нужном статусе",
+			event:           service.OrderEvent{ID: "evt_2", Type: "payment.succeeded", OrderID: 3},
+			claimed:         true,
+			currentStatus:   domain.OrderStatusPaid,
+			expectProcessed: true,
+		},
+		{
+			name:          "Недопустимый переход сохраняет событие",
+			event:         service.OrderEvent{ID: "evt_3", Type: "fulfillment.delivered", OrderID: 3},
+			claimed:       true,
+			currentStatus: domain.OrderStatusPending,
+			expectUpdate:  true,
+			expectedError: domain.ErrConflict,
+		},
+		{
+			name:          "Неизвестный тип события",
+			event:         service.OrderEvent{ID: "evt_4", Type: "payment.exploded", OrderID: 3},
+			expectedError: domain.ErrValidation,
+		},
+		{
+			name:          "Возврат денег событием не выполняется",
+			event:         service.OrderEvent{ID: "evt_7", Type: "payment.refunded", OrderID: 3},
+			expectedError: domain.ErrValidation,
+		},
+		{
+			name:          "Без идентификатора события",
+			event:         service.OrderEvent{Type: "payment.succeeded", OrderID: 3},
+			expectedError: domain.ErrValidation,
+		},
+		{
+			name:          "Слишком длинный идентификатор события",
+			event:         service.OrderEvent{ID: strings.Repeat("e", 129), Type: "payment.succeeded", OrderID: 3},
+			expectedError: domain.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWebhookRepo := new(MockWebhookEventRepository)
+			mockOrderRepo := new(MockOrderRepository)
+			txOrders := new(MockOrderRepository)
+			txProducts := new(MockProductRepository)
+			txPayments := new(MockPaymentRepository)
+			uow := &fakeUnitOfWork{repos: repository.Repositories{Orders: txOrders, Products: txProducts, Payments: txPayments}}
+			orderService := NewOrderService(mockOrderRepo, new(MockCartRepository), new(MockCartItemRepository), new(MockProductRepository), new(MockAddressRepository), uow, newTestEngine(), nil)
+			webhookService := NewWebhookService(mockWebhookRepo, mockOrderRepo, orderService)
+
+			mockWebhookRepo.On("Claim", mock.MatchedBy(func(e *domain.WebhookEvent) bool {
+				return e.EventID == tt.event.ID && e.OrderID == tt.event.OrderID
+			})).Return(tt.claimed, nil).Maybe()
+			mockWebhookRepo.On("Release", tt.event.ID).Return(nil).Maybe()
+			mockOrderRepo.On("GetByID", uint(3)).Return(&domain.Order{ID: 3, Status: tt.currentStatus}, nil).Maybe()
+			txOrders.On("GetByIDForUpdate", uint(3)).Return(&domain.Order{ID: 3, Status: tt.currentStatus}, nil).Maybe()
+			txOrders.On("UpdateStatus", uint(3), mock.Anything).Return(nil).Maybe()
+			txOrders.On("SetCancellation", uint(3), webhookCancellationReason, mock.Anything).Return(nil).Maybe()
+			txPayments.On("GetByOrderID", uint(3)).Return([]domain.Payment{}, nil).Maybe()
+			txOrders.On("AddStatusHistory", mock.MatchedBy(func(h *domain.OrderStatusHistory) bool {
+				return h.ChangedBy == nil
+			})).Return(nil).Maybe()
+
+			processed, err := webhookService.HandleOrderEvent(tt.event)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectProcessed, processed)
+
+			if tt.expectUpdate {
+				txOrders.AssertCalled(t, "GetByIDForUpdate", uint(3))
+			} else {
+				txOrders.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything)
+			}
+			if tt.expectUpdate && tt.expectedError == nil {
+				status := orderEventStatuses[tt.event.Type]
+				txOrders.AssertCalled(t, "UpdateStatus", uint(3), status)
+				txOrders.AssertCalled(t, "AddStatusHistory", mock.Anything)
+				if status == domain.OrderStatusCancelled {
+					txOrders.AssertCalled(t, "SetCancellation", uint(3), webhookCancellationReason, mock.Anything)
+				}
+			}
+			if tt.expectRelease {
+				mockWebhookRepo.AssertCalled(t, "Release", tt.event.ID)
+			} else {
+				mockWebhookRepo.AssertNotCalled(t, "Release", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestHandleOrderEventClaimError(t *testing.T) {
+	mockWebhookRepo := new(MockWebhookEventRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	webhookService := NewWebhookService(mockWebhookRepo, mockOrderRepo, nil)
+
+	dbErr := errors.New("connection refused")
+	mockWebhookRepo.On("Claim", mock.Anything).Return(false, dbErr)
+
+	processed, err := webhookService.HandleOrderEvent(service.OrderEvent{ID: "evt_1", Type: "payment.succeeded", OrderID: 3})
+	assert.ErrorIs(t, err, dbErr)
+	assert.False(t, processed)
+	mockOrderRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+}
+
+func TestHandleOrderEventApplyErrors(t *testing.T) {
+	dbErr := errors.New("connection refused")
+
+	tests := []struct {
+		name          string
+		getErr        error
+		expectRelease bool
+		expectedError error
+	}{
+		{
+			name:          "Временная ошибка освобождает событие",
+			getErr:        dbErr,
+			expectRelease: true,
+			expectedError: dbErr,
+		},
+		{
+			name:          "Несуществующий заказ сохраняет событие",
+			getErr:        gorm.ErrRecordNotFound,
+			expectedError: domain.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWebhookRepo := new(MockWebhookEventRepository)
+			mockOrderRepo := new(MockOrderRepository)
+			webhookService := NewWebhookService(mockWebhookRepo, mockOrderRepo, nil)
+
+			mockWebhookRepo.On("Claim", mock.Anything).Return(true, nil)
+			mockWebhookRepo.On("Release", "evt_1").Return(nil).Maybe()
+			mockOrderRepo.On("GetByID", uint(3)).Return(nil, tt.getErr)
+
+			processed, err := webhookService.HandleOrderEvent(service.OrderEvent{ID: "evt_1", Type: "payment.succeeded", OrderID: 3})
+			assert.ErrorIs(t, err, tt.expectedError)
+			assert.False(t, processed)
+			if tt.expectRelease {
+				mockWebhookRepo.AssertCalled(t, "Release", "evt_1")
+			} else {
+				mockWebhookRepo.AssertNotCalled(t, "Release", mock.Anything)
+			}
+		})
+	}
+}
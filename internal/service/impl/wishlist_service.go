@@ -0,0 +1,186 @@
+package impl
+
+import (
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+	"shopping-cart/internal/service"
+)
+
+// wishlistService реализует интерфейс WishlistService
+type wishlistService struct {
+	wishlistRepo repository.WishlistRepository
+	productRepo  repository.ProductRepository
+	uow          repository.UnitOfWork
+}
+
+// NewWishlistService создает новый экземпляр WishlistService
+func NewWishlistService(wishlistRepo repository.WishlistRepository, productRepo repository.ProductRepository, uow repository.UnitOfWork) service.WishlistService {
+	return &wishlistService{
+		wishlistRepo: wishlistRepo,
+		productRepo:  productRepo,
+		uow:          uow,
+	}
+}
+
+// GetWishlist возвращает список отложенных товаров пользователя
+// Если списка нет, создает пустой
+func (s *wishlistService) GetWishlist(userID uint) (*domain.Wishlist, error) {
+	return s.wishlistRepo.GetOrCreate(userID)
+}
+
+// SaveProduct откладывает товар из каталога
+// Повторное сохранение увеличивает количество, как и добавление в корзину
+func (s *wishlistService) SaveProduct(userID uint, productID uint, quantity int) (*domain.SavedItem, error) {
+	if err := domain.ValidateLineQuantity(quantity); err != nil {
+		return nil, err
+	}
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, wrapNotFound(err, "product")
+	}
+
+	wishlist, err := s.wishlistRepo.GetOrCreate(userID)
+	if err != nil {
+		return nil, err
+	}
+	item := &domain.SavedItem{WishlistID: wishlist.ID, ProductID: productID, Quantity: quantity}
+	if err := s.wishlistRepo.AddItem(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// RemoveSavedItem удаляет товар из списка отложенных
+func (s *wishlistService) RemoveSavedItem(userID uint, itemID uint) error {
+	if _, err := getOwnedSavedItem(s.wishlistRepo, userID, itemID); err != nil {
+		return err
+	}
+	return wrapNotFound(s.wishlistRepo.DeleteItem(itemID), "saved item")
+}
+
+// SaveCartItem переносит позицию корзины пользователя в список отложенных вместе с количеством
+// Если товар уже отложен, количество складывается в пределах domain.MaxLineQuantity
+func (s *wishlistService) SaveCartItem(userID uint, cartItemID uint) (*domain.SavedItem, error) {
+	var saved *domain.SavedItem
+	err := s.uow.Do(func(repos repository.Repositories) error {
+		cart, err := repos.Carts.GetByUserID(userID)
+		if err != nil {
+			return wrapNotFound(err, "cart")
+		}
+		cartItem, err := repos.CartItems.GetByID(cartItemID)
+		if err != nil {
+			return wrapNotFound(err, "cart item")
+		}
+		if cartItem.CartID != cart.ID {
+			return domain.NewForbiddenError("item does not belong to user's cart")
+		}
+
+		wishlist, err := repos.Wishlists.GetOrCreate(userID)
+		if err != nil {
+			return err
+		}
+		item := &domain.SavedItem{WishlistID: wishlist.ID, ProductID: cartItem.ProductID, Quantity: cartItem.Quantity}
+		if err := repos.Wishlists.AddItem(item); err != nil {
+			return err
+		}
+		if err := repos.CartItems.Delete(cartItem.ID); err != nil {
+			return err
+		}
+		saved = item
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// MoveToCart переносит отложенный товар в корзину пользователя по текущей цене
+// Количество и остатки проверяются так же, как при добавлении в корзину
+func (s *wishlistService) MoveToCart(userID uint, itemID uint) error {
+	return s.uow.Do(func(repos repository.Repositories) error {
+		saved, err := getOwnedSavedItem(repos.Wishlists, userID, itemID)
+		if err != nil {
+			return err
+		}
+		product, err := repos.Products.GetByID(saved.ProductID)
+		if err != nil {
+			return wrapNotFound(err, "product")
+		}
+
+		cart, err := repos.Carts.GetOrCreate(domain.CartOwner{UserID: userID})
+		if err != nil {
+			return err
+		}
+		item := &domain.CartItem{
+			CartID:    cart.ID,
+			ProductID: saved.ProductID,
+			Quantity:  saved.Quantity,
+			Price:     product.Price,
+		}
+		if err := repos.CartItems.AddQuantity(item); err != nil {
+			return err
+		}
+		if err := domain.ValidateLineQuantity(item.Quantity); err != nil {
+			return err
+		}
+		if err := checkStock(product, item.Quantity); err != nil {
+			return err
+		}
+		return wrapNotFound(repos.Wishlists.DeleteItem(saved.ID), "saved item")
+	})
+}
+
+// Share публикует список пользователя; уже опубликованный список сохраняет прежний адрес
+func (s *wishlistService) Share(userID uint) (*domain.Wishlist, error) {
+	wishlist, err := s.wishlistRepo.GetOrCreate(userID)
+	if err != nil {
+		return nil, err
+	}
+	if wishlist.IsShared() {
+		return wishlist, nil
+	}
+
+	slug, err := domain.NewWishlistSlug()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.wishlistRepo.SetSlug(wishlist.ID, &slug); err != nil {
+		return nil, err
+	}
+	wishlist.Slug = &slug
+	return wishlist, nil
+}
+
+// Unshare снимает публикацию списка; прежний адрес перестает открываться
+func (s *wishlistService) Unshare(userID uint) error {
+	wishlist, err := s.wishlistRepo.GetOrCreate(userID)
+	if err != nil {
+		return err
+	}
+	return s.wishlistRepo.SetSlug(wishlist.ID, nil)
+}
+
+// GetSharedWishlist возвращает опубликованный список для просмотра без входа
+func (s *wishlistService) GetSharedWishlist(slug string) (*domain.Wishlist, error) {
+	wishlist, err := s.wishlistRepo.GetBySlug(slug)
+	if err != nil {
+		return nil, wrapNotFound(err, "wishlist")
+	}
+	return wishlist, nil
+}
+
+// getOwnedSavedItem возвращает отложенный товар, проверяя, что он в списке пользователя
+func getOwnedSavedItem(wishlists repository.WishlistRepository, userID uint, itemID uint) (*domain.SavedItem, error) {
+	wishlist, err := wishlists.GetOrCreate(userID)
+	if err != nil {
+		return nil, err
+	}
+	item, err := wishlists.GetItem(itemID)
+	if err != nil {
+		return nil, wrapNotFound(err, "saved item")
+	}
+	if item.WishlistID != wishlist.ID {
+		return nil, domain.NewForbiddenError("item does not belong to user's wishlist")
+	}
+	return item, nil
+}
@@ -0,0 +1,264 @@
+package impl
+
+import (
+	"errors"
+	"shopping-cart/internal/domain"
+	"shopping-cart/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockWishlistRepository - мок репозитория списков отложенных товаров
+type MockWishlistRepository struct {
+	mock.Mock
+}
+
+func (m *MockWishlistRepository) GetOrCreate(userID uint) (*domain.Wishlist, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Wishlist), args.Error(1)
+}
+
+func (m *MockWishlistRepository) GetBySlug(slug string) (*domain.Wishlist, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Wishlist), args.Error(1)
+}
+
+func (m *MockWishlistRepository) SetSlug(id uint, slug *string) error {
+	args := m.Called(id, slug)
+	return args.Error(0)
+}
+
+func (m *MockWishlistRepository) GetItem(id uint) (*domain.SavedItem, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SavedItem), args.Error(1)
+}
+
+func (m *MockWishlistRepository) AddItem(item *domain.SavedItem) error {
+	args := m.Called(item)
+	return args.Error(0)
+}
+
+func (m *MockWishlistRepository) DeleteItem(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestSaveProduct(t *testing.T) {
+	tests := []struct {
+		name          string
+		quantity      int
+		productErr    error
+		expectSave    bool
+		expectedError error
+	}{
+		{name: "Товар откладывается", quantity: 2, expectSave: true},
+		{name: "Нулевое количество", quantity: 0, expectedError: domain.ErrValidation},
+		{name: "Товар не найден", quantity: 1, productErr: gorm.ErrRecordNotFound, expectedError: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWishlistRepo := new(MockWishlistRepository)
+			mockProductRepo := new(MockProductRepository)
+			wishlistService := NewWishlistService(mockWishlistRepo, mockProductRepo, &fakeUnitOfWork{})
+
+			if tt.productErr != nil {
+				mockProductRepo.On("GetByID", uint(10)).Return(nil, tt.productErr)
+			} else {
+				mockProductRepo.On("GetByID", uint(10)).Return(&domain.Product{ID: 10, Stock: 5}, nil).Maybe()
+			}
+			mockWishlistRepo.On("GetOrCreate", uint(1)).Return(&domain.Wishlist{ID: 7, UserID: 1}, nil).Maybe()
+			mockWishlistRepo.On("AddItem", mock.MatchedBy(func(i *domain.SavedItem) bool {
+				return i.WishlistID == 7 && i.ProductID == 10 && i.Quantity == tt.quantity
+			})).Return(nil).Maybe()
+
+			item, err := wishlistService.SaveProduct(1, 10, tt.quantity)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, item)
+				mockWishlistRepo.AssertNotCalled(t, "AddItem", mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(7), item.WishlistID)
+			}
+		})
+	}
+}
+
+func TestSaveCartItem(t *testing.T) {
+	tests := []struct {
+		name          string
+		cartItem      *domain.CartItem
+		addErr        error
+		expectedError error
+	}{
+		{
+			name:     "Позиция корзины переносится в отложенные",
+			cartItem: &domain.CartItem{ID: 21, CartID: 5, ProductID: 10, Quantity: 3},
+		},
+		{
+			name:          "Позиция чужой корзины",
+			cartItem:      &domain.CartItem{ID: 21, CartID: 6, ProductID: 10, Quantity: 3},
+			expectedError: domain.ErrForbidden,
+		},
+		{
+			name:          "Ошибка сохранения откатывает перенос",
+			cartItem:      &domain.CartItem{ID: 21, CartID: 5, ProductID: 10, Quantity: 3},
+			addErr:        errors.New("db is down"),
+			expectedError: errors.New("db is down"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txCarts := new(MockCartRepository)
+			txCartItems := new(MockCartItemRepository)
+			txWishlists := new(MockWishlistRepository)
+			uow := &fakeUnitOfWork{repos: repository.Repositories{Carts: txCarts, CartItems: txCartItems, Wishlists: txWishlists}}
+			wishlistService := NewWishlistService(new(MockWishlistRepository), new(MockProductRepository), uow)
+
+			txCarts.On("GetByUserID", uint(1)).Return(&domain.Cart{ID: 5, UserID: uintPtr(1)}, nil)
+			txCartItems.On("GetByID", uint(21)).Return(tt.cartItem, nil)
+			txWishlists.On("GetOrCreate", uint(1)).Return(&domain.Wishlist{ID: 7, UserID: 1}, nil).Maybe()
+			txWishlists.On("AddItem", mock.MatchedBy(func(i *domain.SavedItem) bool {
+				return i.WishlistID == 7 && i.ProductID == 10 && i.Quantity == 3
+			})).Return(tt.addErr).Maybe()
+			txCartItems.On("Delete", uint(21)).Return(nil).Maybe()
+
+			item, err := wishlistService.SaveCartItem(1, 21)
+			if tt.expectedError != nil {
+				if errors.Is(tt.expectedError, domain.ErrForbidden) {
+					assert.ErrorIs(t, err, tt.expectedError)
+				} else {
+					assert.EqualError(t, err, tt.expectedError.Error())
+				}
+				assert.Nil(t, item)
+				assert.True(t, uow.rolledBack)
+				txCartItems.AssertNotCalled(t, "Delete", mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 3, item.Quantity)
+				assert.True(t, uow.committed)
+				txCartItems.AssertCalled(t, "Delete", uint(21))
+			}
+		})
+	}
+}
+
+func TestMoveToCart(t *testing.T) {
+	tests := []struct {
+		name          string
+		saved         *domain.SavedItem
+		inCart        int
+		stock         int
+		expectedError error
+	}{
+		{name: "Товар переносится в корзину", saved: &domain.SavedItem{ID: 41, WishlistID: 7, ProductID: 10, Quantity: 2}, stock: 5},
+		{name: "Товар из чужого списка", saved: &domain.SavedItem{ID: 41, WishlistID: 8, ProductID: 10, Quantity: 2}, stock: 5, expectedError: domain.ErrForbidden},
+		{name: "Не хватает на складе с учетом корзины", saved: &domain.SavedItem{ID: 41, WishlistID: 7, ProductID: 10, Quantity: 2}, inCart: 4, stock: 5, expectedError: domain.ErrInsufficientStock},
+		{name: "Превышен лимит позиции", saved: &domain.SavedItem{ID: 41, WishlistID: 7, ProductID: 10, Quantity: 2}, inCart: domain.MaxLineQuantity, stock: 1000, expectedError: domain.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txCarts := new(MockCartRepository)
+			txCartItems := new(MockCartItemRepository)
+			txProducts := new(MockProductRepository)
+			txWishlists := new(MockWishlistRepository)
+			uow := &fakeUnitOfWork{repos: repository.Repositories{Carts: txCarts, CartItems: txCartItems, Products: txProducts, Wishlists: txWishlists}}
+			wishlistService := NewWishlistService(new(MockWishlistRepository), new(MockProductRepository), uow)
+
+			price := domain.NewMoney(12000, "RUB")
+			txWishlists.On("GetOrCreate", uint(1)).Return(&domain.Wishlist{ID: 7, UserID: 1}, nil)
+			txWishlists.On("GetItem", uint(41)).Return(tt.saved, nil)
+			txProducts.On("GetByID", uint(10)).Return(&domain.Product{ID: 10, Price: price, Stock: tt.stock}, nil).Maybe()
+			txCarts.On("GetOrCreate", customer).Return(&domain.Cart{ID: 5, UserID: uintPtr(1)}, nil).Maybe()
+			// Цена в корзине берется текущая, количество складывается с уже лежащим в корзине
+			txCartItems.On("AddQuantity", mock.MatchedBy(func(i *domain.CartItem) bool {
+				return i.CartID == 5 && i.ProductID == 10 && i.Quantity == 2 && i.Price == price
+			})).Return(nil).Run(func(args mock.Arguments) {
+				args.Get(0).(*domain.CartItem).Quantity += tt.inCart
+			}).Maybe()
+			txWishlists.On("DeleteItem", uint(41)).Return(nil).Maybe()
+
+			err := wishlistService.MoveToCart(1, 41)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.True(t, uow.rolledBack)
+				txWishlists.AssertNotCalled(t, "DeleteItem", mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, uow.committed)
+				txWishlists.AssertCalled(t, "DeleteItem", uint(41))
+			}
+		})
+	}
+}
+
+func TestShareWishlist(t *testing.T) {
+	slug := "0123456789abcdef01234567"
+
+	tests := []struct {
+		name        string
+		wishlist    *domain.Wishlist
+		expectNewID bool
+	}{
+		{name: "Публикация списка", wishlist: &domain.Wishlist{ID: 7, UserID: 1}, expectNewID: true},
+		{name: "Повторная публикация сохраняет адрес", wishlist: &domain.Wishlist{ID: 7, UserID: 1, Slug: &slug}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockWishlistRepo := new(MockWishlistRepository)
+			wishlistService := NewWishlistService(mockWishlistRepo, new(MockProductRepository), &fakeUnitOfWork{})
+
+			mockWishlistRepo.On("GetOrCreate", uint(1)).Return(tt.wishlist, nil)
+			mockWishlistRepo.On("SetSlug", uint(7), mock.AnythingOfType("*string")).Return(nil).Maybe()
+
+			wishlist, err := wishlistService.Share(1)
+			assert.NoError(t, err)
+			assert.True(t, wishlist.IsShared())
+			if tt.expectNewID {
+				assert.Len(t, *wishlist.Slug, 24)
+				mockWishlistRepo.AssertCalled(t, "SetSlug", uint(7), wishlist.Slug)
+			} else {
+				assert.Equal(t, slug, *wishlist.Slug)
+				mockWishlistRepo.AssertNotCalled(t, "SetSlug", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUnshareWishlist(t *testing.T) {
+	mockWishlistRepo := new(MockWishlistRepository)
+	wishlistService := NewWishlistService(mockWishlistRepo, new(MockProductRepository), &fakeUnitOfWork{})
+
+	mockWishlistRepo.On("GetOrCreate", uint(1)).Return(&domain.Wishlist{ID: 7, UserID: 1}, nil)
+	mockWishlistRepo.On("SetSlug", uint(7), (*string)(nil)).Return(nil)
+
+	assert.NoError(t, wishlistService.Unshare(1))
+	mockWishlistRepo.AssertExpectations(t)
+}
+
+func TestGetSharedWishlist(t *testing.T) {
+	mockWishlistRepo := new(MockWishlistRepository)
+	wishlistService := NewWishlistService(mockWishlistRepo, new(MockProductRepository), &fakeUnitOfWork{})
+
+	mockWishlistRepo.On("GetBySlug", "missing").Return(nil, gorm.ErrRecordNotFound)
+
+	wishlist, err := wishlistService.GetSharedWishlist("missing")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Nil(t, wishlist)
+}
@@ -2,30 +2,163 @@ package service
 
 import (
 	"shopping-cart/internal/domain"
+	"time"
+)
+
+var (
+	// ErrEmailTaken возвращается при регистрации на уже занятый email
+	ErrEmailTaken = domain.NewConflictError("email is already registered")
+	// ErrInvalidCredentials возвращается при неверной паре email/пароль
+	ErrInvalidCredentials = domain.NewUnauthorizedError("invalid email or password")
+	// ErrForbidden возвращается, если у пользователя нет доступа к ресурсу
+	ErrForbidden = domain.NewForbiddenError("access denied")
+	// ErrInvalidRole возвращается при попытке назначить неизвестную роль
+	ErrInvalidRole = domain.NewValidationError("invalid role")
+	// ErrCouponInactive возвращается, если срок действия купона не наступил или истек
+	ErrCouponInactive = domain.NewValidationError("coupon is not active")
+	// ErrCouponNotApplicable возвращается, если корзина не удовлетворяет условиям акции
+	ErrCouponNotApplicable = domain.NewValidationError("cart does not meet coupon conditions")
+	// ErrCouponLimitReached возвращается, если лимит использований купона исчерпан
+	ErrCouponLimitReached = domain.NewConflictError("coupon usage limit reached")
+	// ErrOrderNotPayable возвращается при попытке оплатить заказ, который не ожидает оплаты
+	ErrOrderNotPayable = domain.NewConflictError("order is not awaiting payment")
+	// ErrOrderNotReturnable возвращается при попытке вернуть товары заказа, который еще не доставлен или уже возвращен
+	ErrOrderNotReturnable = domain.NewConflictError("order cannot be returned in its current status")
+	// ErrReturnClosed возвращается при повторном решении по заявке на возврат
+	ErrReturnClosed = domain.NewConflictError("return has already been reviewed")
+	// ErrOrderNotCancellable возвращается при попытке отменить заказ, который уже отправлен или закрыт
+	ErrOrderNotCancellable = domain.NewConflictError("order can no longer be cancelled")
+	// ErrIdempotencyKeyReused возвращается, если ключ идемпотентности уже использован для другого запроса
+	ErrIdempotencyKeyReused = domain.NewConflictError("idempotency key was already used for a different request")
+	// ErrIdempotencyKeyInProgress возвращается, если запрос с тем же ключом еще выполняется
+	ErrIdempotencyKeyInProgress = domain.NewConflictError("request with this idempotency key is still in progress")
 )
 
 type CartService interface {
-	AddItem(userID uint, productID uint, quantity int) error
-	RemoveItem(userID uint, itemID uint) error
-	GetCart(userID uint) (*domain.Cart, error)
-	ClearCart(userID uint) error
+	AddItem(owner domain.CartOwner, productID uint, quantity int) error
+	RemoveItem(owner domain.CartOwner, itemID uint) error
+	UpdateItemQuantity(owner domain.CartOwner, itemID uint, quantity int) error
+	ApplyCoupon(owner domain.CartOwner, code string) (*domain.Cart, error)
+	RemoveCoupon(owner domain.CartOwner) error
+	// AcceptPriceChanges подтверждает цены из ошибки price_changed для перечисленных позиций корзины
+	AcceptPriceChanges(owner domain.CartOwner, accepted []AcceptedPrice) (*domain.Cart, error)
+	GetCart(owner domain.CartOwner) (*domain.Cart, error)
+	ClearCart(owner domain.CartOwner) error
+	// MergeCart переносит гостевую корзину в корзину пользователя после входа
+	MergeCart(guestToken string, userID uint) (*domain.Cart, error)
 }
 
+// CheckoutRequest - параметры оформления заказа
+type CheckoutRequest struct {
+	ShippingAddressID uint
+	// BillingAddressID - адрес плательщика; 0 означает адрес доставки
+	BillingAddressID uint
+	ShippingMethod   string
+}
 
 type OrderService interface {
-	CreateOrder(userID uint) (*domain.Order, error)
-	GetOrder(orderID uint) (*domain.Order, error)
+	CreateOrder(userID uint, req CheckoutRequest) (*domain.Order, error)
+	GetOrder(userID uint, role domain.Role, orderID uint) (*domain.Order, error)
 	GetUserOrders(userID uint) ([]domain.Order, error)
-	UpdateOrderStatus(orderID uint, status string) error
+	UpdateOrderStatus(actorID uint, orderID uint, status domain.OrderStatus) error
+	GetOrderHistory(userID uint, role domain.Role, orderID uint) ([]domain.OrderStatusHistory, error)
+	CancelOrder(userID uint, role domain.Role, orderID uint, reason string) (*domain.Order, error)
 }
 
 type ProductService interface {
 	CreateProduct(product *domain.Product) error
 	GetProduct(id uint) (*domain.Product, error)
-	GetAllProducts() ([]domain.Product, error)
+	ListProducts(query domain.ProductQuery) (*domain.ProductPage, error)
 	UpdateProduct(product *domain.Product) error
 	DeleteProduct(id uint) error
-} 
+	SetStock(id uint, stock int) error
+}
 
+type PromotionService interface {
+	CreatePromotion(promotion *domain.Promotion) error
+	GetAllPromotions() ([]domain.Promotion, error)
+}
 
+type PaymentService interface {
+	PayOrder(userID uint, orderID uint, source string) (*domain.Payment, error)
+}
 
+// AcceptedPrice - новая цена позиции корзины, которую покупатель подтвердил после ошибки price_changed
+type AcceptedPrice struct {
+	ItemID   uint
+	NewPrice domain.Money
+}
+
+// ReturnLine - позиция заказа и количество единиц для возврата
+type ReturnLine struct {
+	OrderItemID uint
+	Quantity    int
+}
+
+type ReturnService interface {
+	RequestReturn(userID uint, orderID uint, reason string, lines []ReturnLine) (*domain.OrderReturn, error)
+	GetOrderReturns(userID uint, role domain.Role, orderID uint) ([]domain.OrderReturn, error)
+	ApproveReturn(actorID uint, returnID uint, note string) (*domain.OrderReturn, error)
+	RejectReturn(actorID uint, returnID uint, note string) (*domain.OrderReturn, error)
+}
+
+// OrderEvent - событие внешней системы об изменении заказа
+type OrderEvent struct {
+	// ID - идентификатор события у отправителя, по нему отбрасываются повторы
+	ID      string
+	Type    string
+	OrderID uint
+}
+
+type WebhookService interface {
+	// HandleOrderEvent применяет событие к заказу
+	// Возвращает false, если событие уже было обработано
+	HandleOrderEvent(event OrderEvent) (bool, error)
+}
+
+type IdempotencyService interface {
+	// Begin закрепляет ключ за запросом с отпечатком fingerprint
+	// Возвращает сохраненный ответ, если запрос с этим ключом уже выполнен, или nil,
+	// если запрос нужно выполнить и затем вызвать Complete или Release
+	Begin(userID uint, key string, fingerprint string) (*domain.IdempotencyKey, error)
+	Complete(userID uint, key string, statusCode int, contentType string, body []byte) error
+	Release(userID uint, key string) error
+}
+
+type CartExpiryService interface {
+	// ExpireCarts удаляет корзины, которые не менялись дольше срока хранения,
+	// и возвращает количество удаленных
+	ExpireCarts() (int64, error)
+	// GetAbandonedCarts возвращает до limit корзин с товарами, которые не менялись дольше idle
+	GetAbandonedCarts(idle time.Duration, limit int) ([]domain.AbandonedCart, error)
+}
+
+type WishlistService interface {
+	GetWishlist(userID uint) (*domain.Wishlist, error)
+	SaveProduct(userID uint, productID uint, quantity int) (*domain.SavedItem, error)
+	RemoveSavedItem(userID uint, itemID uint) error
+	// SaveCartItem переносит позицию корзины в список отложенных
+	SaveCartItem(userID uint, cartItemID uint) (*domain.SavedItem, error)
+	// MoveToCart переносит отложенный товар обратно в корзину
+	MoveToCart(userID uint, itemID uint) error
+	// Share публикует список по случайному адресу, Unshare снимает публикацию
+	Share(userID uint) (*domain.Wishlist, error)
+	Unshare(userID uint) error
+	// GetSharedWishlist возвращает опубликованный список по его адресу
+	GetSharedWishlist(slug string) (*domain.Wishlist, error)
+}
+
+type AddressService interface {
+	GetAddresses(userID uint) ([]domain.Address, error)
+	CreateAddress(userID uint, address *domain.Address) error
+	UpdateAddress(userID uint, address *domain.Address) error
+	DeleteAddress(userID uint, addressID uint) error
+}
+
+type UserService interface {
+	Register(email, password, name string) (*domain.User, error)
+	Login(email, password string) (*domain.User, error)
+	ChangePassword(userID uint, oldPassword, newPassword string) error
+	GetUser(id uint) (*domain.User, error)
+	SetRole(userID uint, role domain.Role) error
+}
@@ -0,0 +1,122 @@
+package shipping
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"shopping-cart/internal/domain"
+	"sort"
+)
+
+// Rate - ступень тарифа: стоимость доставки отправления весом до MaxWeightGrams включительно
+type Rate struct {
+	MaxWeightGrams int    `json:"max_weight_grams"`
+	Price          string `json:"price"`
+
+	price domain.Money
+}
+
+// Method - способ доставки с тарифами по зонам
+type Method struct {
+	Name  string            `json:"name"`
+	Rates map[string][]Rate `json:"rates"`
+}
+
+// Table - тарифы доставки: зоны (списки стран) и способы доставки
+type Table struct {
+	Currency string              `json:"currency"`
+	Zones    map[string][]string `json:"zones"`
+	Methods  map[string]Method   `json:"methods"`
+
+	zoneByCountry map[string]string
+}
+
+// NewTable создает таблицу тарифов и проверяет ее корректность
+func NewTable(currency string, zones map[string][]string, methods map[string]Method) (*Table, error) {
+	table := &Table{Currency: currency, Zones: zones, Methods: methods}
+	if err := table.prepare(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// LoadTable загружает таблицу тарифов из JSON-файла
+func LoadTable(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var table Table
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("parse shipping config %s: %w", path, err)
+	}
+	if err := table.prepare(); err != nil {
+		return nil, fmt.Errorf("invalid shipping config %s: %w", path, err)
+	}
+	return &table, nil
+}
+
+// prepare проверяет таблицу, разбирает цены и сортирует ступени тарифов по весу
+func (t *Table) prepare() error {
+	if t.Currency == "" {
+		t.Currency = domain.DefaultCurrency
+	}
+
+	t.zoneByCountry = make(map[string]string)
+	for zone, countries := range t.Zones {
+		for _, country := range countries {
+			if other, ok := t.zoneByCountry[country]; ok {
+				return fmt.Errorf("country %s belongs to zones %s and %s", country, other, zone)
+			}
+			t.zoneByCountry[country] = zone
+		}
+	}
+
+	for code, method := range t.Methods {
+		for zone, rates := range method.Rates {
+			if _, ok := t.Zones[zone]; !ok {
+				return fmt.Errorf("method %s: unknown zone %s", code, zone)
+			}
+			for i := range rates {
+				price, err := domain.ParseMoney(rates[i].Price, t.Currency)
+				if err != nil {
+					return fmt.Errorf("method %s, zone %s: %w", code, zone, err)
+				}
+				rates[i].price = price
+			}
+			sort.Slice(rates, func(i, j int) bool { return rates[i].MaxWeightGrams < rates[j].MaxWeightGrams })
+		}
+	}
+	return nil
+}
+
+// HasMethod сообщает, существует ли способ доставки
+func (t *Table) HasMethod(method string) bool {
+	_, ok := t.Methods[method]
+	return ok
+}
+
+// Quote рассчитывает стоимость доставки отправления весом weightGrams в страну country
+func (t *Table) Quote(method, country string, weightGrams int) (domain.Money, error) {
+	m, ok := t.Methods[method]
+	if !ok {
+		return domain.Money{}, domain.NewValidationError(fmt.Sprintf("unknown shipping method %q", method))
+	}
+
+	zone, ok := t.zoneByCountry[country]
+	if !ok {
+		return domain.Money{}, domain.NewValidationError(fmt.Sprintf("shipping to %q is not available", country))
+	}
+	rates, ok := m.Rates[zone]
+	if !ok {
+		return domain.Money{}, domain.NewValidationError(fmt.Sprintf("shipping method %q is not available for %q", method, country))
+	}
+
+	for _, rate := range rates {
+		if weightGrams <= rate.MaxWeightGrams {
+			return rate.price, nil
+		}
+	}
+	return domain.Money{}, domain.NewValidationError(fmt.Sprintf("parcel of %d g is too heavy for shipping method %q", weightGrams, method))
+}
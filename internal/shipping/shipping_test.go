@@ -0,0 +1,69 @@
+package shipping
+
+import (
+	"os"
+	"path/filepath"
+	"shopping-cart/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableQuote(t *testing.T) {
+	table, err := NewTable("RUB", map[string][]string{
+		"domestic":   {"RU"},
+		"neighbours": {"BY"},
+	}, map[string]Method{
+		"standard": {Name: "Почта", Rates: map[string][]Rate{
+			"domestic":   {{MaxWeightGrams: 5000, Price: "500"}, {MaxWeightGrams: 1000, Price: "300"}},
+			"neighbours": {{MaxWeightGrams: 1000, Price: "700"}},
+		}},
+		"express": {Name: "Курьер", Rates: map[string][]Rate{
+			"domestic": {{MaxWeightGrams: 5000, Price: "900"}},
+		}},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		method        string
+		country       string
+		weight        int
+		expected      domain.Money
+		expectedError error
+	}{
+		{name: "Легкая посылка", method: "standard", country: "RU", weight: 800, expected: domain.NewMoney(30000, "RUB")},
+		{name: "Граница ступени тарифа", method: "standard", country: "RU", weight: 1000, expected: domain.NewMoney(30000, "RUB")},
+		{name: "Следующая ступень тарифа", method: "standard", country: "RU", weight: 1001, expected: domain.NewMoney(50000, "RUB")},
+		{name: "Другая зона", method: "standard", country: "BY", weight: 500, expected: domain.NewMoney(70000, "RUB")},
+		{name: "Слишком тяжелая посылка", method: "standard", country: "BY", weight: 1500, expectedError: domain.ErrValidation},
+		{name: "Способ недоступен в зоне", method: "express", country: "BY", weight: 500, expectedError: domain.ErrValidation},
+		{name: "Страна вне зон доставки", method: "standard", country: "US", weight: 500, expectedError: domain.ErrValidation},
+		{name: "Неизвестный способ", method: "drone", country: "RU", weight: 500, expectedError: domain.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, err := table.Quote(tt.method, tt.country, tt.weight)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, price)
+		})
+	}
+}
+
+func TestLoadTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shipping.json")
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"zones":{"domestic":["RU"]},"methods":{"standard":{"rates":{"domestic":[{"max_weight_grams":1000,"price":"300.00"}]}}}}`), 0o600))
+	table, err := LoadTable(path)
+	assert.NoError(t, err)
+	assert.True(t, table.HasMethod("standard"))
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"zones":{"domestic":["RU"]},"methods":{"standard":{"rates":{"abroad":[{"max_weight_grams":1000,"price":"300.00"}]}}}}`), 0o600))
+	_, err = LoadTable(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,123 @@
+package tax
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"shopping-cart/internal/domain"
+)
+
+// Mode определяет, включен ли налог в цену товара
+type Mode string
+
+const (
+	// ModeExclusive - цены указаны без налога, налог добавляется к итогу
+	ModeExclusive Mode = "exclusive"
+	// ModeInclusive - цены уже содержат налог, налог выделяется из суммы
+	ModeInclusive Mode = "inclusive"
+)
+
+// DefaultCategory - налоговая категория товара по умолчанию
+// Должна быть задана в каждом регионе и используется, если категория товара в регионе не описана
+const DefaultCategory = "standard"
+
+// basisPoints - 100% в базисных пунктах
+const basisPoints = 10000
+
+// Rates - ставки налога по категориям товаров в базисных пунктах (2000 = 20%)
+type Rates map[string]int
+
+// Table - таблица налоговых ставок по регионам
+type Table struct {
+	Mode          Mode             `json:"mode"`
+	DefaultRegion string           `json:"default_region"`
+	Regions       map[string]Rates `json:"regions"`
+}
+
+// NewTable создает таблицу ставок и проверяет ее корректность
+func NewTable(mode Mode, defaultRegion string, regions map[string]Rates) (*Table, error) {
+	table := &Table{Mode: mode, DefaultRegion: defaultRegion, Regions: regions}
+	if err := table.validate(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// LoadTable загружает таблицу ставок из JSON-файла
+func LoadTable(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var table Table
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("parse tax config %s: %w", path, err)
+	}
+	if err := table.validate(); err != nil {
+		return nil, fmt.Errorf("invalid tax config %s: %w", path, err)
+	}
+	return &table, nil
+}
+
+func (t *Table) validate() error {
+	if t.Mode != ModeExclusive && t.Mode != ModeInclusive {
+		return fmt.Errorf("unknown tax mode %q", t.Mode)
+	}
+	if _, ok := t.Regions[t.DefaultRegion]; !ok {
+		return fmt.Errorf("default region %q is not configured", t.DefaultRegion)
+	}
+	for region, rates := range t.Regions {
+		if _, ok := rates[DefaultCategory]; !ok {
+			return fmt.Errorf("region %q has no %q rate", region, DefaultCategory)
+		}
+		for category, rate := range rates {
+			if rate < 0 || rate > basisPoints {
+				return fmt.Errorf("region %q: rate for %q must be between 0 and %d", region, category, basisPoints)
+			}
+		}
+	}
+	return nil
+}
+
+// Inclusive сообщает, включен ли налог в цены
+func (t *Table) Inclusive() bool {
+	return t.Mode == ModeInclusive
+}
+
+// HasCategory сообщает, описана ли категория в регионе по умолчанию
+func (t *Table) HasCategory(category string) bool {
+	_, ok := t.Regions[t.DefaultRegion][category]
+	return ok
+}
+
+// Rate возвращает ставку для категории товара в регионе
+// Пустой регион означает регион по умолчанию
+func (t *Table) Rate(region, category string) (int, error) {
+	if region == "" {
+		region = t.DefaultRegion
+	}
+	rates, ok := t.Regions[region]
+	if !ok {
+		return 0, domain.NewValidationError(fmt.Sprintf("no tax rates for region %q", region))
+	}
+	if rate, ok := rates[category]; ok {
+		return rate, nil
+	}
+	return rates[DefaultCategory], nil
+}
+
+// Tax рассчитывает налог для суммы по ставке с округлением до минимальной единицы валюты
+// В режиме exclusive налог начисляется сверху, в режиме inclusive выделяется из суммы
+func (t *Table) Tax(amount domain.Money, rate int) domain.Money {
+	if t.Inclusive() {
+		net := roundDiv(amount.Amount*basisPoints, int64(basisPoints+rate))
+		return domain.NewMoney(amount.Amount-net, amount.Currency)
+	}
+	return domain.NewMoney(roundDiv(amount.Amount*int64(rate), basisPoints), amount.Currency)
+}
+
+// roundDiv делит с округлением половины вверх, для неотрицательных значений
+func roundDiv(a, b int64) int64 {
+	return (a + b/2) / b
+}
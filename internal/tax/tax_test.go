@@ -0,0 +1,59 @@
+package tax
+
+import (
+	"os"
+	"path/filepath"
+	"shopping-cart/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableTax(t *testing.T) {
+	regions := map[string]Rates{
+		"RU": {DefaultCategory: 2000, "reduced": 1000},
+		"KZ": {DefaultCategory: 1200},
+	}
+	exclusive, err := NewTable(ModeExclusive, "RU", regions)
+	assert.NoError(t, err)
+	inclusive, err := NewTable(ModeInclusive, "RU", regions)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		table    *Table
+		region   string
+		category string
+		amount   domain.Money
+		expected domain.Money
+	}{
+		{name: "Налог сверху цены", table: exclusive, category: DefaultCategory, amount: domain.NewMoney(1000, "RUB"), expected: domain.NewMoney(200, "RUB")},
+		{name: "Пониженная ставка", table: exclusive, category: "reduced", amount: domain.NewMoney(1005, "RUB"), expected: domain.NewMoney(101, "RUB")},
+		{name: "Налог в цене", table: inclusive, category: DefaultCategory, amount: domain.NewMoney(1200, "RUB"), expected: domain.NewMoney(200, "RUB")},
+		{name: "Неизвестная категория считается по основной ставке", table: exclusive, region: "KZ", category: "reduced", amount: domain.NewMoney(1000, "RUB"), expected: domain.NewMoney(120, "RUB")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := tt.table.Rate(tt.region, tt.category)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, tt.table.Tax(tt.amount, rate))
+		})
+	}
+
+	_, err = exclusive.Rate("US", DefaultCategory)
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
+
+func TestLoadTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tax.json")
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"mode":"exclusive","default_region":"RU","regions":{"RU":{"standard":2000}}}`), 0o600))
+	table, err := LoadTable(path)
+	assert.NoError(t, err)
+	assert.True(t, table.HasCategory(DefaultCategory))
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"mode":"exclusive","default_region":"RU","regions":{"RU":{"reduced":1000}}}`), 0o600))
+	_, err = LoadTable(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature возвращается, если заголовок подписи поврежден или подпись не совпадает
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrStaleSignature возвращается, если подпись создана слишком давно или в будущем
+	ErrStaleSignature = errors.New("webhook signature timestamp is outside the tolerance")
+	// ErrNotConfigured возвращается, если секрет для проверки подписи не задан
+	ErrNotConfigured = errors.New("webhook secret is not configured")
+)
+
+// SignatureHeader - заголовок, в котором отправитель передает подпись
+const SignatureHeader = "X-Webhook-Signature"
+
+// Verifier проверяет подпись входящего вебхука
+type Verifier interface {
+	Verify(header string, payload []byte) error
+}
+
+// Signer подписывает и проверяет тела вебхуков HMAC-SHA256
+// Подпись передается в виде "t=<unix-время>,v1=<hex-подпись>" и вычисляется от строки
+// "<unix-время>.<тело>", поэтому перехваченный запрос нельзя повторить позже tolerance
+type Signer struct {
+	secret    []byte
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+// NewSigner создает новый экземпляр Signer
+// С пустым секретом все подписи считаются недействительными
+func NewSigner(secret string, tolerance time.Duration) *Signer {
+	return &Signer{
+		secret:    []byte(secret),
+		tolerance: tolerance,
+		now:       time.Now,
+	}
+}
+
+// Sign возвращает значение заголовка подписи для тела payload, отправленного в момент timestamp
+func (s *Signer) Sign(payload []byte, timestamp time.Time) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + unix + ",v1=" + hex.EncodeToString(s.mac(unix, payload))
+}
+
+// Verify проверяет подпись тела payload из заголовка header
+func (s *Signer) Verify(header string, payload []byte) error {
+	if len(s.secret) == 0 {
+		return ErrNotConfigured
+	}
+
+	var unix string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidSignature
+		}
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			signature, err := hex.DecodeString(value)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			signatures = append(signatures, signature)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	age := s.now().Sub(time.Unix(seconds, 0))
+	if age > s.tolerance || age < -s.tolerance {
+		return ErrStaleSignature
+	}
+
+	// Несколько подписей v1 допускаются на время смены секрета у отправителя
+	expected := s.mac(unix, payload)
+	for _, signature := range signatures {
+		if hmac.Equal(signature, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// mac вычисляет HMAC-SHA256 от строки "<unix-время>.<тело>"
+func (s *Signer) mac(unix string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignerVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signer := NewSigner("secret", 5*time.Minute)
+	signer.now = func() time.Time { return now }
+	payload := []byte(`{"id":"evt_1","type":"payment.succeeded","order_id":5}`)
+
+	tests := []struct {
+		name          string
+		signer        *Signer
+		header        string
+		payload       []byte
+		expectedError error
+	}{
+		{name: "Валидная подпись", signer: signer, header: signer.Sign(payload, now), payload: payload},
+		{name: "Подпись в пределах допуска", signer: signer, header: signer.Sign(payload, now.Add(-4*time.Minute)), payload: payload},
+		{name: "Измененное тело", signer: signer, header: signer.Sign(payload, now), payload: []byte(`{"id":"evt_1","type":"payment.succeeded","order_id":6}`), expectedError: ErrInvalidSignature},
+		{name: "Чужой секрет", signer: signer, header: NewSigner("other", time.Minute).Sign(payload, now), payload: payload, expectedError: ErrInvalidSignature},
+		{name: "Устаревшая подпись", signer: signer, header: signer.Sign(payload, now.Add(-10*time.Minute)), payload: payload, expectedError: ErrStaleSignature},
+		{name: "Подпись из будущего", signer: signer, header: signer.Sign(payload, now.Add(10*time.Minute)), payload: payload, expectedError: ErrStaleSignature},
+		{name: "Нет заголовка", signer: signer, header: "", payload: payload, expectedError: ErrInvalidSignature},
+		{name: "Нет подписи", signer: signer, header: "t=1700000000", payload: payload, expectedError: ErrInvalidSignature},
+		{name: "Секрет не задан", signer: NewSigner("", time.Minute), header: signer.Sign(payload, now), payload: payload, expectedError: ErrNotConfigured},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.signer.Verify(tt.header, tt.payload)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSignerVerifyDuringSecretRotation(t *testing.T) {
+	now := time.Now()
+	payload := []byte(`{}`)
+	oldSigner := NewSigner("old", time.Minute)
+	newSigner := NewSigner("new", time.Minute)
+
+	_, newSignature, _ := strings.Cut(newSigner.Sign(payload, now), ",")
+	header := oldSigner.Sign(payload, now) + "," + newSignature
+	assert.NoError(t, newSigner.Verify(header, payload))
+	assert.NoError(t, oldSigner.Verify(header, payload))
+}
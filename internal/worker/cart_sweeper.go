@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"shopping-cart/internal/service"
+	"time"
+)
+
+// CartSweeper периодически удаляет просроченные корзины
+type CartSweeper struct {
+	carts    service.CartExpiryService
+	interval time.Duration
+}
+
+// NewCartSweeper создает фоновую очистку корзин, запускаемую раз в interval
+func NewCartSweeper(carts service.CartExpiryService, interval time.Duration) *CartSweeper {
+	return &CartSweeper{
+		carts:    carts,
+		interval: interval,
+	}
+}
+
+// Run удаляет просроченные корзины сразу после запуска и затем раз в interval,
+// пока не будет отменен ctx. Начатая очистка завершается до выхода из Run
+func (s *CartSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep выполняет одну очистку; ошибка пишется в лог, следующая попытка - по расписанию
+func (s *CartSweeper) sweep() {
+	expired, err := s.carts.ExpireCarts()
+	if err != nil {
+		log.Printf("cart sweeper: %v", err)
+	}
+	if expired > 0 {
+		log.Printf("cart sweeper: %d expired carts deleted", expired)
+	}
+}
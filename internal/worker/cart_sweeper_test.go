@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"shopping-cart/internal/domain"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingExpiryService считает запуски очистки и сообщает о каждом в канал
+type countingExpiryService struct {
+	calls atomic.Int32
+	swept chan struct{}
+	err   error
+}
+
+func (s *countingExpiryService) ExpireCarts() (int64, error) {
+	s.calls.Add(1)
+	select {
+	case s.swept <- struct{}{}:
+	default:
+	}
+	return 1, s.err
+}
+
+func (s *countingExpiryService) GetAbandonedCarts(time.Duration, int) ([]domain.AbandonedCart, error) {
+	return nil, nil
+}
+
+func TestCartSweeper(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "Очистка по расписанию"},
+		{name: "Ошибка очистки не останавливает расписание", err: errors.New("db is down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			carts := &countingExpiryService{swept: make(chan struct{}, 1), err: tt.err}
+			sweeper := NewCartSweeper(carts, time.Millisecond)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				sweeper.Run(ctx)
+				close(done)
+			}()
+
+			// Первая очистка сразу после запуска, вторая - по таймеру
+			for range 2 {
+				select {
+				case <-carts.swept:
+				case <-time.After(time.Second):
+					t.Fatal("sweep was not started")
+				}
+			}
+
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("sweeper did not stop after cancellation")
+			}
+			assert.GreaterOrEqual(t, carts.calls.Load(), int32(2))
+		})
+	}
+}